@@ -0,0 +1,25 @@
+'use strict'
+
+const assert = require('assert');
+const run = require('bindings')('addon');
+
+const ops = ['float64s', 'strings', 'object', 'elements'];
+const size = 10000;
+const iterations = 200;
+const input = Array.from({ length: size }, (_, i) => i);
+
+function bench(op, bulk) {
+  const start = process.hrtime.bigint();
+  for (let i = 0; i < iterations; i++) {
+    run(op, bulk, size, input);
+  }
+  return Number(process.hrtime.bigint() - start) / 1e6 / iterations;
+}
+
+ops.forEach((name, op) => {
+  assert.deepStrictEqual(run(op, true, size, input), run(op, false, size, input));
+  const element = bench(op, false);
+  const bulk = bench(op, true);
+  console.log(`${name}: per-element ${element.toFixed(3)} ms, bulk ${bulk.toFixed(3)} ms ` +
+    `(${(element / bulk).toFixed(1)}x)`);
+});
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"C"
+	"go-napi-sys/napisys"
+	"strconv"
+	"unsafe"
+)
+
+const (
+	opFloat64s = iota
+	opStrings
+	opObject
+	opElements
+)
+
+func float64s(env napisys.Env, n int, bulk bool) napisys.Value {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(i) / 2
+	}
+	if bulk {
+		res, _ := napisys.NewArrayFromFloat64s(env, values)
+		return res
+	}
+	res, _ := napisys.CreateArrayWithLength(env, uint(n))
+	for i, v := range values {
+		element, _ := napisys.CreateDouble(env, v)
+		napisys.SetElement(env, res, uint(i), element)
+	}
+	return res
+}
+
+func strings(env napisys.Env, n int, bulk bool) napisys.Value {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = "item-" + strconv.Itoa(i)
+	}
+	if bulk {
+		res, _ := napisys.NewArrayFromStrings(env, values)
+		return res
+	}
+	res, _ := napisys.CreateArrayWithLength(env, uint(n))
+	for i, v := range values {
+		element, _ := napisys.CreateStringUtf8(env, v)
+		napisys.SetElement(env, res, uint(i), element)
+	}
+	return res
+}
+
+func object(env napisys.Env, n int, bulk bool) napisys.Value {
+	keys := make([]string, n)
+	values := make([]napisys.Value, n)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+		values[i], _ = napisys.CreateInt32(env, int32(i))
+	}
+	res, _ := napisys.CreateObject(env)
+	if bulk {
+		napisys.SetNamedProperties(env, res, keys, values)
+		return res
+	}
+	for i, key := range keys {
+		napisys.SetNamedProperty(env, res, key, values[i])
+	}
+	return res
+}
+
+func elements(env napisys.Env, array napisys.Value, bulk bool) napisys.Value {
+	var values []napisys.Value
+	if bulk {
+		values, _ = napisys.GetElements(env, array)
+	} else {
+		length, _ := napisys.GetArrayLength(env, array)
+		values = make([]napisys.Value, length)
+		for i := range values {
+			values[i], _ = napisys.GetElement(env, array, uint(i))
+		}
+	}
+	res, _ := napisys.CreateUInt32(env, uint32(len(values)))
+	return res
+}
+
+func run(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+	params, _, _, _ := napisys.GetCbInfo(env, info)
+	op, _ := napisys.GetValueInt32(env, params[0])
+	bulk, _ := napisys.GetValueBool(env, params[1])
+	n, _ := napisys.GetValueInt32(env, params[2])
+	switch op {
+	case opFloat64s:
+		return float64s(env, int(n), bulk)
+	case opStrings:
+		return strings(env, int(n), bulk)
+	case opObject:
+		return object(env, int(n), bulk)
+	default:
+		return elements(env, params[3], bulk)
+	}
+}
+
+//export Initialize
+func Initialize(env unsafe.Pointer, exports unsafe.Pointer) unsafe.Pointer {
+	newExports, _ := napisys.CreateFunction((napisys.Env)(env), "", run)
+	return unsafe.Pointer(newExports)
+}
+
+func main() {}
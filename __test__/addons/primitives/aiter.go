@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"go-napi-sys/napisys"
+	"sync/atomic"
+)
+
+var aiterCancelled, aiterReleased atomic.Int32
+
+func init() {
+	// aiterCount(n, failure) iterates over 0..n-1, then fails with failure
+	// when it is not empty. The producer reports its outcome once on errc
+	// and never closes it; it stops early once done is closed.
+	register("aiterCount", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		n, _ := args.Int32(0)
+		failure, _ := args.StringOr(1, "")
+		ch := make(chan int32)
+		errc := make(chan error, 1)
+		done := make(chan struct{})
+		go func() {
+			defer close(ch)
+			for i := int32(0); i < n; i++ {
+				select {
+				case ch <- i:
+				case <-done:
+					aiterCancelled.Add(1)
+					return
+				}
+			}
+			var err error
+			if failure != "" {
+				err = errors.New(failure)
+			}
+			errc <- err
+		}()
+		return napisys.NewAsyncIteratorWithDone(env, ch, errc, done), nil
+	})
+	// aiterUnbounded() iterates over 0, 1, ... with a producer that only
+	// stops when it cannot send anymore, and counts it as released once it
+	// has sent its last value.
+	register("aiterUnbounded", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		ch := make(chan int32)
+		go func() {
+			defer aiterReleased.Add(1)
+			defer close(ch)
+			for i := int32(0); i < 1000; i++ {
+				ch <- i
+			}
+		}()
+		return napisys.NewAsyncIterator(env, ch, nil), nil
+	})
+	register("aiterCancelled", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return aiterCancelled.Load(), nil
+	})
+	register("aiterReleased", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return aiterReleased.Load(), nil
+	})
+}
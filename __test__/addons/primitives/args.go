@@ -0,0 +1,100 @@
+package main
+
+import "go-napi-sys/napisys"
+
+// argsValue converts v with ToValue, throwing the error if it fails.
+func argsValue(env napisys.Env, v interface{}) napisys.Value {
+	res, err := napisys.ToValue(env, v)
+	if err != nil {
+		return throw(env, err)
+	}
+	return res
+}
+
+func init() {
+	// argsInfo(...args) describes what GetArgs reads for the call.
+	registerCallback("argsInfo", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		args, status := napisys.GetArgs(env, info)
+		if err := check(status); err != nil {
+			return throw(env, err)
+		}
+		return argsValue(env, map[string]interface{}{
+			"len":    args.Len(),
+			"values": args.Values(),
+			"this":   args.This(),
+			"at":     []napisys.Value{args.At(0), args.At(args.Len() - 1), args.At(args.Len()), args.At(-1)},
+			"has":    []bool{args.Has(0), args.Has(1), args.Has(args.Len())},
+		})
+	})
+	// argsTyped(int32, float64, bool, string, function, object, int32) reads
+	// each argument with the accessor of its type, the last one past the
+	// arguments stored inline.
+	registerCallback("argsTyped", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		args, _ := napisys.GetArgs(env, info)
+		i, ok := args.Int32(0)
+		if !ok {
+			return nil
+		}
+		f, ok := args.Float64(1)
+		if !ok {
+			return nil
+		}
+		b, ok := args.Bool(2)
+		if !ok {
+			return nil
+		}
+		s, ok := args.String(3)
+		if !ok {
+			return nil
+		}
+		fn, ok := args.Func(4)
+		if !ok {
+			return nil
+		}
+		obj, ok := args.Object(5)
+		if !ok {
+			return nil
+		}
+		last, ok := args.Int32(6)
+		if !ok {
+			return nil
+		}
+		return argsValue(env, []interface{}{i, f, b, s, fn, obj, last})
+	})
+	// argsDefaults(int32, float64, bool, string, function, object) reads each
+	// argument with the accessor of its type ending with Or.
+	registerCallback("argsDefaults", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		args, _ := napisys.GetArgs(env, info)
+		defaultObject, _ := napisys.CreateObject(env)
+		i, ok := args.Int32Or(0, 7)
+		if !ok {
+			return nil
+		}
+		f, ok := args.Float64Or(1, 2.5)
+		if !ok {
+			return nil
+		}
+		b, ok := args.BoolOr(2, true)
+		if !ok {
+			return nil
+		}
+		s, ok := args.StringOr(3, "default")
+		if !ok {
+			return nil
+		}
+		fn, ok := args.FuncOr(4, nil)
+		if !ok {
+			return nil
+		}
+		obj, ok := args.ObjectOr(5, defaultObject)
+		if !ok {
+			return nil
+		}
+		return argsValue(env, []interface{}{i, f, b, s, fn, obj})
+	})
+	// argsThrow() throws a RangeError with a code through Args.Throw.
+	registerCallback("argsThrow", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		args, _ := napisys.GetArgs(env, info)
+		return args.Throw(&napisys.JSError{Name: "RangeError", Message: "out of range", Code: "ERR_OUT_OF_RANGE"})
+	})
+}
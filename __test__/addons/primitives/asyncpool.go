@@ -0,0 +1,103 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+const napiCancelled = 11
+
+// poolStatuses records the status given to the complete callback of every
+// work, whatever the environment that queued it.
+var poolStatuses struct {
+	sync.Mutex
+	list []int32
+}
+
+// int32Array converts values to a JavaScript array.
+func int32Array(env napisys.Env, values []int32) (napisys.Value, error) {
+	res, status := napisys.CreateArrayWithLength(env, uint(len(values)))
+	if err := check(status); err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		element, _ := napisys.CreateInt32(env, v)
+		if err := check(napisys.SetElement(env, res, uint(i), element)); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func init() {
+	// poolRun(count, ms, cancelLast, requeue) runs count works sleeping ms on
+	// a pool of a single goroutine and resolves with their completion
+	// statuses. With requeue, a cancelled work is queued again and the status
+	// of QueueAsyncWork is recorded after its own.
+	registerCallback("poolRun", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		count, _ := napisys.GetValueInt32(env, param(params, 0))
+		ms, _ := napisys.GetValueInt32(env, param(params, 1))
+		cancelLast, _ := napisys.GetValueBool(env, param(params, 2))
+		requeue, _ := napisys.GetValueBool(env, param(params, 3))
+		napisys.UseAsyncPool(napisys.AsyncPoolOptions{Workers: 1})
+		promise, deferred, status := napisys.CreatePromise(env)
+		if err := check(status); err != nil {
+			return throw(env, err)
+		}
+		statuses := make([]int32, count)
+		remaining := count
+		works := make([]napisys.AsyncWork, count)
+		for i := range works {
+			i := i
+			execute := &napisys.AsyncExecuteCaller{Cb: func(env napisys.Env, data unsafe.Pointer) {
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+			}}
+			complete := &napisys.AsyncCompleteCaller{Cb: func(env napisys.Env, status napisys.Status, data unsafe.Pointer) {
+				poolStatuses.Lock()
+				poolStatuses.list = append(poolStatuses.list, int32(status))
+				if requeue && status == napiCancelled {
+					poolStatuses.list = append(poolStatuses.list, int32(napisys.QueueAsyncWork(env, works[i])))
+				}
+				poolStatuses.Unlock()
+				napisys.DeleteAsyncWork(env, works[i])
+				statuses[i] = int32(status)
+				if remaining--; remaining == 0 {
+					res, err := int32Array(env, statuses)
+					if err != nil {
+						throw(env, err)
+						return
+					}
+					napisys.ResolveDeferred(env, deferred, res)
+				}
+			}}
+			work, status := napisys.CreateAsyncWork(env, nil, nil, execute, complete, nil)
+			if err := check(status); err != nil {
+				return throw(env, err)
+			}
+			works[i] = work
+		}
+		for _, work := range works {
+			if err := check(napisys.QueueAsyncWork(env, work)); err != nil {
+				return throw(env, err)
+			}
+		}
+		if cancelLast {
+			if err := check(napisys.CancelAsyncWork(env, works[count-1])); err != nil {
+				return throw(env, err)
+			}
+		}
+		return promise
+	})
+	registerCallback("poolStatuses", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		poolStatuses.Lock()
+		defer poolStatuses.Unlock()
+		res, err := int32Array(env, poolStatuses.list)
+		if err != nil {
+			return throw(env, err)
+		}
+		return res
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"go-napi-sys/napisys"
+	"time"
+)
+
+// awaitTornDown receives the outcomes awaited by environments that shut down.
+var awaitTornDown = make(chan napisys.Result, 1)
+
+// awaitOutcome describes r as {value} or {error, jsError, name}.
+func awaitOutcome(r napisys.Result) map[string]interface{} {
+	if r.Err != nil {
+		var jsErr *napisys.JSError
+		outcome := map[string]interface{}{"error": r.Err.Error(), "jsError": errors.As(r.Err, &jsErr)}
+		if jsErr != nil {
+			outcome["name"] = jsErr.Name
+		}
+		return outcome
+	}
+	return map[string]interface{}{"value": r.Value}
+}
+
+func init() {
+	// awaitValue(value) awaits value with Await and resolves with its
+	// outcome.
+	register("awaitValue", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		ch := napisys.Await(env, args.At(0))
+		promise, p, err := napisys.NewPromise(env)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			p.Resolve(awaitOutcome(<-ch))
+		}()
+		return promise, nil
+	})
+	// awaitFunc(value) awaits value with AwaitFunc and resolves with its
+	// outcome.
+	register("awaitFunc", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		promise, p, err := napisys.NewPromise(env)
+		if err != nil {
+			return nil, err
+		}
+		napisys.AwaitFunc(env, args.At(0), func(r napisys.Result) {
+			p.Resolve(awaitOutcome(r))
+		})
+		return promise, nil
+	})
+	// awaitForever(value) awaits value and sends its outcome to
+	// awaitTornDown.
+	register("awaitForever", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		napisys.AwaitFunc(env, args.At(0), func(r napisys.Result) {
+			awaitTornDown <- r
+		})
+		return nil, nil
+	})
+	// awaitTornDownOutcome() returns the outcome sent to awaitTornDown, or
+	// null if none arrives within a second.
+	register("awaitTornDownOutcome", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		select {
+		case r := <-awaitTornDown:
+			return awaitOutcome(r), nil
+		case <-time.After(time.Second):
+			return nil, nil
+		}
+	})
+}
@@ -0,0 +1,14 @@
+{
+  "targets": [
+    {
+      "target_name": "addon",
+      "sources": [ "addon.cc" ],
+      "include_dirs": [
+        "include"
+      ],
+      "libraries": [
+        "<(module_root_dir)/libgoaddon.a"
+      ]
+    }
+  ]
+}
\ No newline at end of file
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+	"strconv"
+	"strings"
+)
+
+// bulkStatus returns status as a Number, for the tests that expect a failure.
+func bulkStatus(env napisys.Env, status napisys.Status) napisys.Value {
+	res, _ := napisys.CreateInt32(env, int32(status))
+	return res
+}
+
+// bulkString returns the string expected at index i by the bulk tests, made
+// of multi-byte characters so that their lengths differ in bytes and UTF-16
+// code units.
+func bulkString(i int) string {
+	return strings.Repeat("é", i%4) + strconv.Itoa(i)
+}
+
+func init() {
+	// bulkFloat64s(n) returns [0, 0.5, 1, ...] with n elements.
+	registerCallback("bulkFloat64s", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		n, _ := napisys.GetValueInt32(env, param(params, 0))
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = float64(i) / 2
+		}
+		res, status := napisys.NewArrayFromFloat64s(env, values)
+		if err := check(status); err != nil {
+			return throw(env, err)
+		}
+		return res
+	})
+	// bulkInt32s(n) returns [-n/2, ..., n/2 - 1] with n elements.
+	registerCallback("bulkInt32s", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		n, _ := napisys.GetValueInt32(env, param(params, 0))
+		values := make([]int32, n)
+		for i := range values {
+			values[i] = int32(i) - n/2
+		}
+		res, status := napisys.NewArrayFromInt32s(env, values)
+		if err := check(status); err != nil {
+			return throw(env, err)
+		}
+		return res
+	})
+	// bulkStrings(n) returns n strings built by bulkString.
+	registerCallback("bulkStrings", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		n, _ := napisys.GetValueInt32(env, param(params, 0))
+		values := make([]string, n)
+		for i := range values {
+			values[i] = bulkString(i)
+		}
+		res, status := napisys.NewArrayFromStrings(env, values)
+		if err := check(status); err != nil {
+			return throw(env, err)
+		}
+		return res
+	})
+	// bulkValues(n) returns [0, 1, ...] with n elements, created one by one
+	// and stored with NewArrayFromValues.
+	registerCallback("bulkValues", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		n, _ := napisys.GetValueInt32(env, param(params, 0))
+		values := make([]napisys.Value, n)
+		for i := range values {
+			values[i], _ = napisys.CreateInt32(env, int32(i))
+		}
+		res, status := napisys.NewArrayFromValues(env, values)
+		if err := check(status); err != nil {
+			return throw(env, err)
+		}
+		return res
+	})
+	// bulkProperties(n, extraKey) returns an object with the properties
+	// bulkString(i) = i, or the status of SetNamedProperties when extraKey
+	// makes it get one key more than values.
+	registerCallback("bulkProperties", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		n, _ := napisys.GetValueInt32(env, param(params, 0))
+		extraKey, _ := napisys.GetValueBool(env, param(params, 1))
+		keys := make([]string, n)
+		values := make([]napisys.Value, n)
+		for i := range keys {
+			keys[i] = bulkString(i)
+			values[i], _ = napisys.CreateInt32(env, int32(i))
+		}
+		if extraKey {
+			keys = append(keys, "extra")
+		}
+		res, _ := napisys.CreateObject(env)
+		if status := napisys.SetNamedProperties(env, res, keys, values); status != 0 {
+			return bulkStatus(env, status)
+		}
+		return res
+	})
+	// bulkElements(array) returns a copy of array made with GetElements, or
+	// the status of GetElements when it fails.
+	registerCallback("bulkElements", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		elements, status := napisys.GetElements(env, param(params, 0))
+		if status != 0 {
+			return bulkStatus(env, status)
+		}
+		res, status := napisys.NewArrayFromValues(env, elements)
+		if err := check(status); err != nil {
+			return throw(env, err)
+		}
+		return res
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"go-napi-sys/napisys"
+)
+
+// callNode is a linked list node, used to build cyclic and deeply nested
+// values for ToValue.
+type callNode struct {
+	Next *callNode `json:"next"`
+}
+
+// callResult describes the outcome of a call as {value} or {error, name,
+// code, jsError}, where jsError tells whether err is a *napisys.JSError.
+func callResult(env napisys.Env, res napisys.Value, err error) napisys.Value {
+	var outcome map[string]interface{}
+	if err != nil {
+		var jsErr *napisys.JSError
+		isJSError := errors.As(err, &jsErr)
+		outcome = map[string]interface{}{"error": err.Error(), "jsError": isJSError}
+		if isJSError {
+			outcome["name"], outcome["code"] = jsErr.Name, jsErr.Code
+		}
+	} else {
+		outcome = map[string]interface{}{"value": res}
+	}
+	v, err := napisys.ToValue(env, outcome)
+	if err != nil {
+		return throw(env, err)
+	}
+	return v
+}
+
+// callArgs returns params[from:] as arguments for Call, New and CallMethod.
+func callArgs(params []napisys.Value, from int) []interface{} {
+	var args []interface{}
+	for i := from; i < len(params); i++ {
+		args = append(args, params[i])
+	}
+	return args
+}
+
+func init() {
+	// callFunction(fn, this, ...args) calls fn with the given this and args.
+	registerCallback("callFunction", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		res, err := napisys.Call(env, param(params, 0), param(params, 1), callArgs(params, 2)...)
+		return callResult(env, res, err)
+	})
+	// callGoValues(fn) calls fn with undefined as this and Go values of
+	// several kinds as arguments.
+	registerCallback("callGoValues", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		res, err := napisys.Call(env, param(params, 0), nil,
+			"text", 1.5, []int{1, 2}, map[string]bool{"ok": true}, callNode{}, nil)
+		return callResult(env, res, err)
+	})
+	// newInstance(ctor, ...args) instantiates ctor with args.
+	registerCallback("newInstance", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		res, err := napisys.New(env, param(params, 0), callArgs(params, 1)...)
+		return callResult(env, res, err)
+	})
+	// callMethod(object, name, ...args) calls the method name of object.
+	// name is fixed on the Go side, as "run", or "missing" when a second
+	// argument is given as true.
+	registerCallback("callMethod", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		name := "run"
+		if missing, _ := napisys.GetValueBool(env, param(params, 1)); missing {
+			name = "missing"
+		}
+		res, err := napisys.CallMethod(env, param(params, 0), name, callArgs(params, 2)...)
+		return callResult(env, res, err)
+	})
+	// toValueNested(depth, cyclic) converts a list of depth nodes, whose last
+	// node points back to the first one when cyclic is true.
+	registerCallback("toValueNested", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		depth, _ := napisys.GetValueInt32(env, param(params, 0))
+		cyclic, _ := napisys.GetValueBool(env, param(params, 1))
+		first := &callNode{}
+		last := first
+		for i := int32(1); i < depth; i++ {
+			last.Next = &callNode{}
+			last = last.Next
+		}
+		if cyclic {
+			last.Next = first
+		}
+		res, err := napisys.ToValue(env, first)
+		return callResult(env, res, err)
+	})
+	// toValueCyclicMap converts a map that contains itself.
+	registerCallback("toValueCyclicMap", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		m := map[string]interface{}{}
+		m["self"] = m
+		res, err := napisys.ToValue(env, m)
+		return callResult(env, res, err)
+	})
+}
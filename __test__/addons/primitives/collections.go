@@ -0,0 +1,20 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+)
+
+func init() {
+	// roundTrip converts a value to Go and back.
+	register("roundTrip", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return napisys.FromValue(env, args.At(0))
+	})
+	// collections returns Go maps that do not convert to plain objects.
+	register("collections", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return map[string]interface{}{
+			"byID":  map[int64]string{1: "one", 1<<53 + 1: "big"},
+			"flags": map[string]struct{}{"a": {}},
+			"sizes": map[float64]int{0.5: 1},
+		}, nil
+	})
+}
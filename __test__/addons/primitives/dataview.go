@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/binary"
+	"go-napi-sys/napisys"
+)
+
+var (
+	be = binary.BigEndian
+	le = binary.LittleEndian
+)
+
+func init() {
+	register("writeDataView", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		res, v, err := napisys.NewDataView(env, 32)
+		if err != nil {
+			return nil, err
+		}
+		v.SetUint16(0, 0x0102, be)
+		v.SetInt32(2, -5, le)
+		v.SetFloat64(6, 3.25, be)
+		v.SetBigInt64(14, -7, le)
+		n, _ := v.SetUvarint(22, 300)
+		m, _ := v.SetVarint(22+n, -2)
+		v.SetInt8(22+n+m, -1)
+		v.SetFloat32(28, 1.5, le)
+		return res, nil
+	})
+	register("readDataView", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		v, err := napisys.GetDataView(env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		u16, _ := v.Uint16(0, be)
+		u16le, _ := v.Uint16(0, le)
+		i32, _ := v.Int32(2, le)
+		f64, _ := v.Float64(6, be)
+		i64, _ := v.BigInt64(14, le)
+		uv, n, _ := v.Uvarint(22)
+		sv, m, _ := v.Varint(22 + n)
+		i8, _ := v.Int8(22 + n + m)
+		f32, _ := v.Float32(28, le)
+		return []interface{}{v.Len(), u16, u16le, i32, f64, i64, uv, n, sv, m, i8, f32}, nil
+	})
+	// dataViewBounds runs the accessor named by its second argument at the
+	// offset given by the third one.
+	register("dataViewBounds", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		v, err := napisys.GetDataView(env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		op, _ := args.String(1)
+		i, _ := args.Int32(2)
+		offset := int(i)
+		switch op {
+		case "uint8":
+			return v.Uint8(offset)
+		case "uint32":
+			return v.Uint32(offset, be)
+		case "setFloat64":
+			return nil, v.SetFloat64(offset, 1, le)
+		case "uvarint":
+			x, _, err := v.Uvarint(offset)
+			return x, err
+		case "setUvarint":
+			_, err := v.SetUvarint(offset, 1<<40)
+			return nil, err
+		}
+		return nil, nil
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+)
+
+func init() {
+	// emitterTicks returns an emitter that emits 'tick' n times from a
+	// goroutine, then 'end'.
+	register("emitterTicks", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		n, _ := args.Int32(0)
+		value, e, err := napisys.NewEmitter(env)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			defer e.Close()
+			for i := int32(0); i < n; i++ {
+				e.Emit("tick", i, map[string]int32{"square": i * i})
+			}
+			e.Emit("end")
+		}()
+		return value, nil
+	})
+	// emitterIdle returns an emitter that is never closed.
+	register("emitterIdle", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		value, _, err := napisys.NewEmitter(env)
+		return value, err
+	})
+}
@@ -0,0 +1,18 @@
+package main
+
+import "go-napi-sys/napisys"
+
+func init() {
+	register("eval", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		source, _ := args.StringOr(0, "")
+		return napisys.Eval(env, source)
+	})
+	register("require", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		id, _ := args.StringOr(0, "")
+		return napisys.Require(env, id)
+	})
+	register("moduleFileName", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		filename, status := napisys.GetModuleFileName(env)
+		return filename, check(status)
+	})
+}
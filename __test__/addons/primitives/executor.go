@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"go-napi-sys/napisys"
+)
+
+// executorEnv is the environment of the worker that called executorRemember.
+var executorEnv napisys.Env
+
+func init() {
+	// invokeInline calls Invoke on the main thread and uses the returned
+	// value after filling the handle scope with other values.
+	registerCallback("invokeInline", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		if err := napisys.StartExecutor(env); err != nil {
+			return throw(env, err)
+		}
+		res, err := napisys.Invoke(context.Background(), env, func(env napisys.Env) (napisys.Value, error) {
+			obj, status := napisys.CreateObject(env)
+			if err := check(status); err != nil {
+				return nil, err
+			}
+			answer, _ := napisys.CreateInt32(env, 42)
+			return obj, check(napisys.SetNamedProperty(env, obj, "answer", answer))
+		})
+		if err != nil {
+			return throw(env, err)
+		}
+		for i := 0; i < 100; i++ {
+			napisys.CreateStringUtf8(env, "filler")
+		}
+		return res
+	})
+	// invokeFromGoroutine resolves with the sum of the numbers from 0 to n-1,
+	// each one created and read back by a call to Invoke made from a
+	// goroutine.
+	registerCallback("invokeFromGoroutine", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		params, _, _, _ := napisys.GetCbInfo(env, info)
+		n, _ := napisys.GetValueInt32(env, param(params, 0))
+		if err := napisys.StartExecutor(env); err != nil {
+			return throw(env, err)
+		}
+		release, err := napisys.KeepAlive(env)
+		if err != nil {
+			return throw(env, err)
+		}
+		promise, deferred, _ := napisys.CreatePromise(env)
+		go func() {
+			defer release()
+			var sum int32
+			for i := int32(0); i < n; i++ {
+				x, err := napisys.Invoke(context.Background(), env, func(env napisys.Env) (int32, error) {
+					v, status := napisys.CreateInt32(env, i)
+					if err := check(status); err != nil {
+						return 0, err
+					}
+					x, status := napisys.GetValueInt32(env, v)
+					return x, check(status)
+				})
+				if err != nil {
+					sum = -1
+					break
+				}
+				sum += x
+			}
+			napisys.Post(env, func(env napisys.Env) {
+				res, _ := napisys.CreateInt32(env, sum)
+				napisys.ResolveDeferred(env, deferred, res)
+			})
+		}()
+		return promise
+	})
+	// invokeThrow returns the error Invoke reports for a pending exception.
+	registerCallback("invokeThrow", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		if err := napisys.StartExecutor(env); err != nil {
+			return throw(env, err)
+		}
+		_, err := napisys.Invoke(context.Background(), env, func(env napisys.Env) (struct{}, error) {
+			napisys.ThrowError(env, "thrown on the main thread", "")
+			return struct{}{}, nil
+		})
+		res, _ := napisys.CreateStringUtf8(env, err.Error())
+		return res
+	})
+	// executorRemember starts the executor of the calling environment and
+	// remembers it for executorPostLater.
+	registerCallback("executorRemember", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		if err := napisys.StartExecutor(env); err != nil {
+			return throw(env, err)
+		}
+		executorEnv = env
+		return nil
+	})
+	// executorPostLater returns the error of Post on the remembered
+	// environment.
+	registerCallback("executorPostLater", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		err := napisys.Post(executorEnv, func(napisys.Env) {})
+		if err == nil {
+			return nil
+		}
+		res, _ := napisys.CreateStringUtf8(env, err.Error())
+		return res
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import "go-napi-sys/napisys"
+
+type frozenConfig struct {
+	Name   string            `json:"name"`
+	Limits map[string]int    `json:"limits"`
+	Tags   []string          `json:"tags"`
+	Blob   []byte            `json:"blob"`
+	Nested map[string][]bool `json:"nested"`
+}
+
+type frozenPoint struct{ X, Y int }
+
+var frozenCfg = &frozenConfig{
+	Name:   "app",
+	Limits: map[string]int{"a": 1},
+	Tags:   []string{"x"},
+	Blob:   []byte{1},
+	Nested: map[string][]bool{"n": {true}},
+}
+
+func init() {
+	register("frozenConfig", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return napisys.Frozen(frozenCfg), nil
+	})
+	register("frozenCopy", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		c := *frozenCfg
+		return napisys.Frozen(&c), nil
+	})
+	register("frozenPoint", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return napisys.Frozen(frozenPoint{1, 2}), nil
+	})
+	register("frozenNested", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return map[string]interface{}{"frozen": napisys.Frozen(frozenCfg), "mutable": []int{1}}, nil
+	})
+	// frozenCollections freezes values that become Maps and Sets, which
+	// Frozen rejects.
+	register("frozenCollections", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		switch s, _ := args.String(0); s {
+		case "map":
+			return napisys.Frozen(map[int]string{1: "one"}), nil
+		case "set":
+			return napisys.Frozen(map[string]struct{}{"a": {}}), nil
+		}
+		return napisys.Frozen(&struct{ ByID map[int64]string }{map[int64]string{1: "one"}}), nil
+	})
+	register("freezeValue", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return napisys.Frozen(args.At(0)), nil
+	})
+	register("deepFreeze", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return args.At(0), napisys.DeepFreeze(env, args.At(0))
+	})
+}
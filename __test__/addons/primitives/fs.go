@@ -0,0 +1,30 @@
+package main
+
+import (
+	"embed"
+	"go-napi-sys/napisys"
+	"io/fs"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+func init() {
+	// newFS exposes the testdata directory. With embedded, it is exposed as
+	// the embed.FS itself, whose paths start with testdata/, and otherwise as
+	// fs.Sub of it, which NewFS copies from.
+	register("newFS", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		embedded, ok := args.BoolOr(0, false)
+		if !ok {
+			return nil, nil
+		}
+		if embedded {
+			return napisys.NewFS(env, testdata)
+		}
+		sub, err := fs.Sub(testdata, "testdata")
+		if err != nil {
+			return nil, err
+		}
+		return napisys.NewFS(env, sub)
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"go-napi-sys/napisys"
+	"runtime"
+)
+
+// functionMallocs is the number of heap allocations counted by fnAllocsBegin.
+var functionMallocs uint64
+
+// functionArgc is the number of arguments of the last call to fnLen.
+var functionArgc int
+
+func mallocs() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Mallocs
+}
+
+func init() {
+	register("fnEcho", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return map[string]interface{}{"this": args.This(), "args": args.Values()}, nil
+	})
+	register("fnFirst", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return args.At(0), nil
+	})
+	register("fnLen", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		functionArgc = args.Len()
+		return nil, nil
+	})
+	register("fnError", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		if coded, _ := args.BoolOr(0, false); coded {
+			return nil, &napisys.JSError{Name: "RangeError", Message: "coded failure", Code: "ERR_CODED"}
+		}
+		return nil, errors.New("plain failure")
+	})
+	register("fnPanic", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		panic("boom")
+	})
+	register("fnPending", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		napisys.ThrowTypeError(env, "left pending", "ERR_PENDING")
+		return "ignored", nil
+	})
+	register("fnUnconvertible", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return make(chan int), nil
+	})
+	register("fnAllocsBegin", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		functionMallocs = mallocs()
+		return nil, nil
+	})
+	register("fnAllocsEnd", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return float64(mallocs() - functionMallocs), nil
+	})
+	register("fnLastLen", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return functionArgc, nil
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-napi-sys/napisys"
+	"sync/atomic"
+	"time"
+)
+
+var groupCancelled atomic.Int32
+
+// sleeper returns a task completing with v and err after ms milliseconds,
+// unless it is cancelled first.
+func sleeper(ms int, v interface{}, err error) napisys.Task {
+	return func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+			return v, err
+		case <-ctx.Done():
+			groupCancelled.Add(1)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func init() {
+	register("group", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		op, _ := args.String(0)
+		// The asyncpool test leaves the pool enabled with a single worker, on
+		// which the tasks of a group would run one after the other.
+		napisys.UseAsyncPool(napisys.AsyncPoolOptions{Workers: 8})
+		slow := sleeper(300, "slow", nil)
+		bad := sleeper(20, nil, errors.New("bad"))
+		switch op {
+		case "all":
+			return napisys.All(env, []napisys.Task{sleeper(30, "first", nil), sleeper(5, 2, nil), func(context.Context) (interface{}, error) {
+				return []int{1, 2}, nil
+			}})
+		case "allFailing":
+			return napisys.All(env, []napisys.Task{sleeper(10, "ok", nil), bad, slow})
+		case "allSettled":
+			return napisys.AllSettled(env, []napisys.Task{sleeper(30, "ok", nil), bad, func(context.Context) (interface{}, error) {
+				panic("boom")
+			}})
+		case "race":
+			return napisys.Race(env, []napisys.Task{slow, bad, sleeper(5, "fast", nil)})
+		case "any":
+			return napisys.Any(env, []napisys.Task{bad, sleeper(40, "later", nil), slow})
+		case "anyFailing":
+			return napisys.Any(env, []napisys.Task{bad, sleeper(1, nil, &napisys.JSError{Name: "TypeError", Message: "t"})})
+		case "empty":
+			return napisys.All(env, nil)
+		}
+		return nil, fmt.Errorf("unknown group %q", op)
+	})
+	register("groupCancelled", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return groupCancelled.Load(), nil
+	})
+}
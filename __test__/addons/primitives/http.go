@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"go-napi-sys/napisys"
+	"io"
+	"net/http"
+)
+
+// httpErrors receives the error returned by the writes of /bad-header.
+var httpErrors = make(chan error, 1)
+
+func httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Multi", "a")
+		w.Header().Add("X-Multi", "b")
+		fmt.Fprintf(w, "hello %s %s q=%s x-test=%s", r.Method, r.URL.Path, r.URL.Query().Get("q"), r.Header.Get("X-Test"))
+	})
+	// /echo streams the request body back, then reports its length.
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusCreated)
+		n, err := io.Copy(w, r.Body)
+		fmt.Fprintf(w, "|%d %v %d", n, err, r.ContentLength)
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("kaboom")
+	})
+	// /bad-header makes ServerResponse.writeHead throw.
+	mux.HandleFunc("/bad-header", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Bad", "a\nb")
+		_, err := w.Write([]byte("unreachable"))
+		if err == nil {
+			_, err = w.Write([]byte("unreachable"))
+		}
+		httpErrors <- err
+	})
+	return mux
+}
+
+func init() {
+	register("httpListener", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		if err := napisys.StartExecutor(env); err != nil {
+			return nil, err
+		}
+		return napisys.HTTPListener(env, httpHandler())
+	})
+	register("httpLastError", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		err := <-httpErrors
+		if err == nil {
+			return nil, nil
+		}
+		return err.Error(), nil
+	})
+}
@@ -0,0 +1,25 @@
+'use strict'
+
+const addon = require('bindings')('addon');
+
+const tests = ['executor', 'asyncpool', 'bulk', 'call', 'args', 'promise', 'function', 'await', 'group', 'aiter', 'iterate', 'seq', 'emitter', 'readable', 'streamio', 'http', 'fs', 'json', 'collections', 'symbols', 'keys', 'freeze', 'tagged', 'dataview', 'layout', 'eval'];
+
+(async () => {
+  const only = process.argv.slice(2);
+  for (const name of tests) {
+    if (only.length && !only.includes(name)) {
+      continue;
+    }
+    await require(`./test/${name}`)(addon);
+    console.log(`${name}: ok`);
+  }
+  // Nothing left should keep the process alive: a pending promise or a
+  // forgotten reference on the executor makes this timer fire.
+  setTimeout(() => {
+    console.error('the event loop is still alive after the tests');
+    process.exit(1);
+  }, 5000).unref();
+})().catch((err) => {
+  console.error(err);
+  process.exit(1);
+});
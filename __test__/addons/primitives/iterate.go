@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"go-napi-sys/napisys"
+)
+
+func init() {
+	// iterate collects up to limit values of a synchronous iterable, ending
+	// with the message of the error that stopped it, if any.
+	register("iterate", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		limit, _ := args.Int32Or(1, 1000)
+		res := []interface{}{}
+		for v, err := range napisys.IterateJS(env, args.At(0)) {
+			if err != nil {
+				res = append(res, "error: "+err.Error())
+				break
+			}
+			if len(res) >= int(limit) {
+				break
+			}
+			g, err := napisys.FromValue(env, v)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, g)
+		}
+		return res, nil
+	})
+	// iterateAsync collects up to limit values of an async iterable from a
+	// goroutine.
+	register("iterateAsync", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		limit, _ := args.Int32Or(1, 1000)
+		ctx, cancel := context.WithCancel(context.Background())
+		ch, err := napisys.AsyncIterateJS(ctx, env, args.At(0))
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		value, promise, err := napisys.NewPromise(env)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		go func() {
+			defer cancel()
+			res := []interface{}{}
+			for r := range ch {
+				if r.Err != nil {
+					res = append(res, "error: "+r.Err.Error())
+					continue
+				}
+				if b, ok := r.Value.([]byte); ok {
+					r.Value = "bytes: " + string(b)
+				}
+				res = append(res, r.Value)
+				if len(res) >= int(limit) {
+					cancel()
+				}
+			}
+			promise.Resolve(res)
+		}()
+		return value, nil
+	})
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"go-napi-sys/napisys"
+)
+
+type rawDocument struct {
+	Name     string          `json:"name"`
+	Extra    json.RawMessage `json:"extra"`
+	Optional json.RawMessage `json:"optional,omitempty"`
+}
+
+func init() {
+	register("parseJSON", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		s, _ := args.StringOr(0, "")
+		return napisys.FromJSONBytes(env, []byte(s))
+	})
+	register("stringifyJSON", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		b, err := napisys.ToJSONBytes(env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	})
+	register("rawJSON", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return rawDocument{Name: "x", Extra: json.RawMessage(`{"a":[1,2,{"b":null}]}`)}, nil
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+)
+
+func init() {
+	// writableKeys returns the writable keys of an object, including those of
+	// its prototype chain when the second argument is true.
+	register("writableKeys", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		inherited, ok := args.BoolOr(1, false)
+		if !ok {
+			return nil, nil
+		}
+		mode := napisys.KeyCollectionMode(napisys.KeyCollectionModes.OwnOnly)
+		if inherited {
+			mode = napisys.KeyCollectionMode(napisys.KeyCollectionModes.IncludePrototypes)
+		}
+		return napisys.Keys(env, args.At(0), mode, napisys.KeyFilter(napisys.KeyFilters.Writable))
+	})
+	// entries returns the entries of an object and the exception that ended
+	// them, if any.
+	register("entries", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		res := [][]interface{}{}
+		for key, value := range napisys.Entries(env, args.At(0)) {
+			res = append(res, []interface{}{key, value})
+		}
+		if pending, _ := napisys.IsExceptionPending(env); pending {
+			exception, _ := napisys.GetAndClearLastException(env)
+			return []interface{}{res, exception}, nil
+		}
+		return []interface{}{res, nil}, nil
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/binary"
+	"go-napi-sys/napisys"
+)
+
+type tick struct {
+	ID     uint32 `json:"id"`
+	Live   bool   `json:"live"`
+	_      [3]byte
+	Price  float64  `json:"price"`
+	Volume int64    `json:"volume"`
+	Sym    [4]byte  `json:"sym"`
+	Hist   [3]int16 `json:"hist"`
+	Secret uint8    `json:"-"`
+}
+
+var tickLayouts = map[string]*napisys.Layout[tick]{}
+
+// ticks are the records of the last buffer allocated by newTicks.
+var ticks *napisys.Records[tick]
+
+func init() {
+	for name, order := range map[string]binary.ByteOrder{"le": binary.LittleEndian, "be": binary.BigEndian} {
+		l, err := napisys.NewLayout[tick](order)
+		if err != nil {
+			panic(err)
+		}
+		tickLayouts[name] = l
+	}
+	register("tickClass", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		order, _ := args.String(0)
+		return tickLayouts[order].Class(env, "Tick")
+	})
+	register("newTicks", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		order, _ := args.String(0)
+		count, _ := args.Int32(1)
+		buf, r, err := tickLayouts[order].NewBuffer(env, int(count))
+		if err != nil {
+			return nil, err
+		}
+		ticks = r
+		return buf, r.Store(1, tick{ID: 7, Live: true, Price: 1.25, Volume: -1 << 40, Sym: [4]byte{'A', 'B', 'C', 'D'}, Hist: [3]int16{-1, 2, 3}, Secret: 9})
+	})
+	register("loadTick", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		i, _ := args.Int32(0)
+		t, err := ticks.Load(int(i))
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{t.ID, t.Live, t.Price, t.Volume, string(t.Sym[:]), t.Hist[:], t.Secret}, nil
+	})
+	register("viewTicks", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		order, _ := args.String(0)
+		offset, _ := args.Int32(2)
+		r, err := tickLayouts[order].View(env, args.At(1), int(offset))
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]uint32, r.Len())
+		for i := range ids {
+			t, _ := r.Load(i)
+			ids[i] = t.ID
+		}
+		return ids, nil
+	})
+	// layoutErrors returns the errors of layouts that cannot be made.
+	register("layoutErrors", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		var errs []string
+		for _, newLayout := range []func() error{
+			func() error {
+				_, err := napisys.NewLayout[struct{ N int }](binary.BigEndian)
+				return err
+			},
+			func() error {
+				_, err := napisys.NewLayout[struct {
+					C uint8 `json:"constructor"`
+				}](binary.BigEndian)
+				return err
+			},
+			func() error {
+				_, err := napisys.NewLayout[struct {
+					P uint8 `json:"__proto__"`
+				}](binary.BigEndian)
+				return err
+			},
+			func() error {
+				_, err := napisys.NewLayout[struct {
+					ID  uint32
+					Key uint32 `json:"ID"`
+				}](binary.BigEndian)
+				return err
+			},
+		} {
+			errs = append(errs, newLayout().Error())
+		}
+		return errs, nil
+	})
+}
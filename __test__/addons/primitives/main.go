@@ -0,0 +1,70 @@
+package main
+
+import (
+	"C"
+	"go-napi-sys/napisys"
+	"unsafe"
+)
+
+// callbacks holds the native callbacks exported by the addon, registered from
+// the init function of the file testing each primitive.
+var callbacks = map[string]napisys.CCallback{}
+
+func registerCallback(name string, cb napisys.CCallback) {
+	callbacks[name] = cb
+}
+
+// cases holds the Go functions exported by the addon through NewFunction,
+// registered like callbacks.
+var cases = map[string]napisys.Func{}
+
+func register(name string, fn napisys.Func) {
+	cases[name] = fn
+}
+
+// check turns a failed N-API status into an error.
+func check(status napisys.Status) error {
+	if status != 0 {
+		return &napisys.StatusError{Status: status}
+	}
+	return nil
+}
+
+// param returns the argument at index i of params, as read by GetCbInfo, or
+// nil when the caller passed fewer arguments.
+func param(params []napisys.Value, i int) napisys.Value {
+	if i < len(params) {
+		return params[i]
+	}
+	return nil
+}
+
+// throw throws err as a JavaScript Error and returns the result of a callback
+// that failed.
+func throw(env napisys.Env, err error) napisys.Value {
+	napisys.ThrowError(env, err.Error(), "")
+	return nil
+}
+
+//export Initialize
+func Initialize(env unsafe.Pointer, exports unsafe.Pointer) unsafe.Pointer {
+	for name, cb := range callbacks {
+		f, status := napisys.CreateFunction((napisys.Env)(env), name, cb)
+		if err := check(status); err != nil {
+			throw((napisys.Env)(env), err)
+			return nil
+		}
+		napisys.SetNamedProperty((napisys.Env)(env), (napisys.Value)(exports), name, f)
+	}
+	for name, fn := range cases {
+		f, err := napisys.NewFunction((napisys.Env)(env), name, fn)
+		if err != nil {
+			throw((napisys.Env)(env), err)
+			return nil
+		}
+		napisys.SetNamedProperty((napisys.Env)(env), (napisys.Value)(exports), name, f)
+	}
+	return exports
+}
+
+func main() {}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"go-napi-sys/napisys"
+	"runtime"
+	"time"
+)
+
+// promiseLate receives the error of the second settlement of promises
+// settled twice.
+var promiseLate = make(chan error, 1)
+
+// promiseKept holds promises that are never settled.
+var promiseKept []*napisys.Promise
+
+func init() {
+	// promiseSettle(op) returns a promise settled as described by op.
+	registerCallback("promiseSettle", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		args, _ := napisys.GetArgs(env, info)
+		op, ok := args.String(0)
+		if !ok {
+			return nil
+		}
+		value, p, err := napisys.NewPromise(env)
+		if err != nil {
+			return args.Throw(err)
+		}
+		switch op {
+		case "twice":
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				p.Resolve(map[string]int{"a": 1})
+				promiseLate <- p.Reject(errors.New("late"))
+			}()
+		case "reject":
+			go p.Reject(&napisys.JSError{Name: "TypeError", Code: "ERR_TEST", Message: "bad"})
+		case "sync":
+			p.Resolve(42)
+			promiseLate <- p.Resolve(1)
+		case "unconvertible":
+			go p.Resolve(make(chan int))
+		case "abandon":
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				runtime.GC()
+				runtime.GC()
+			}()
+		case "keep":
+			promiseKept = append(promiseKept, p)
+		}
+		return value
+	})
+	// promiseLate() returns the error of the last second settlement.
+	registerCallback("promiseLate", func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		res, _ := napisys.ToValue(env, (<-promiseLate).Error())
+		return res
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"go-napi-sys/napisys"
+	"io"
+	"sync/atomic"
+)
+
+var readableReads, readableCloses atomic.Int32
+
+// counting counts the reads and closes of a reader.
+type counting struct {
+	r io.Reader
+}
+
+func (c *counting) Read(p []byte) (int, error) {
+	readableReads.Add(1)
+	return c.r.Read(p)
+}
+
+func (c *counting) Close() error {
+	readableCloses.Add(1)
+	return nil
+}
+
+// zeros is an endless reader.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// failing reads "abc" n times, then fails.
+type failing struct {
+	n int
+}
+
+func (f *failing) Read(p []byte) (int, error) {
+	if f.n == 0 {
+		return 0, errors.New("disk on fire")
+	}
+	f.n--
+	return copy(p, "abc"), nil
+}
+
+func init() {
+	register("readable", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		op, _ := args.String(0)
+		switch op {
+		case "big":
+			r := bytes.NewReader(bytes.Repeat([]byte("0123456789"), 100000))
+			return napisys.NewReadable(env, &counting{r}, napisys.ReadableOptions{HighWaterMark: 1024})
+		case "endless":
+			return napisys.NewReadable(env, &counting{zeros{}}, napisys.ReadableOptions{HighWaterMark: 100})
+		case "failing":
+			return napisys.NewReadable(env, &failing{2}, napisys.ReadableOptions{})
+		}
+		return nil, nil
+	})
+	register("readableStats", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return map[string]int32{"reads": readableReads.Load(), "closes": readableCloses.Load()}, nil
+	})
+}
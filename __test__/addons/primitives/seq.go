@@ -0,0 +1,46 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+	"iter"
+	"sync/atomic"
+)
+
+// seqStopped counts the sequences that returned.
+var seqStopped atomic.Int32
+
+func counter(n int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		defer seqStopped.Add(1)
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func init() {
+	register("seqCount", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		n, _ := args.Int32Or(0, 1<<30)
+		return napisys.NewIterator(env, counter(int(n)))
+	})
+	register("seqPairs", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return napisys.NewIterator2(env, func(yield func(string, int) bool) {
+			for i, k := range []string{"a", "b"} {
+				if !yield(k, i+1) {
+					return
+				}
+			}
+		})
+	})
+	register("seqPanic", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return napisys.NewIterator(env, func(yield func(int) bool) {
+			yield(1)
+			panic("seq broke")
+		})
+	})
+	register("seqStopped", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return seqStopped.Load(), nil
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+	"io"
+)
+
+// settle resolves promise with the message of err, or null.
+func settle(promise *napisys.Promise, err error) {
+	if err != nil {
+		promise.Resolve(err.Error())
+		return
+	}
+	promise.Resolve(nil)
+}
+
+func init() {
+	// streamCopy copies a Readable into a Writable from a goroutine, then
+	// closes the writer, and resolves with the error of the copy, if any.
+	register("streamCopy", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		r, err := napisys.NewStreamReader(env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		w, err := napisys.NewStreamWriter(env, args.At(1))
+		if err != nil {
+			return nil, err
+		}
+		value, promise, err := napisys.NewPromise(env)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			defer r.Close()
+			_, err := io.Copy(w, r)
+			if cerr := w.Close(); err == nil {
+				err = cerr
+			}
+			settle(promise, err)
+		}()
+		return value, nil
+	})
+	// streamReadAll reads a Readable to its end from a goroutine, resolving
+	// with what was read and the error of the read, if any.
+	register("streamReadAll", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		r, err := napisys.NewStreamReader(env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		value, promise, err := napisys.NewPromise(env)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			b, err := io.ReadAll(r)
+			r.Close()
+			var msg interface{}
+			if err != nil {
+				msg = err.Error()
+			}
+			promise.Resolve([]interface{}{string(b), msg})
+		}()
+		return value, nil
+	})
+	// streamWriteClose writes s to a Writable and closes it, resolving with
+	// the error of Close, if any.
+	register("streamWriteClose", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		s, _ := args.String(1)
+		w, err := napisys.NewStreamWriter(env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		value, promise, err := napisys.NewPromise(env)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			if _, err := io.WriteString(w, s); err != nil {
+				w.Close()
+				settle(promise, err)
+				return
+			}
+			settle(promise, w.Close())
+		}()
+		return value, nil
+	})
+}
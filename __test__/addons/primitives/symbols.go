@@ -0,0 +1,46 @@
+package main
+
+import (
+	"go-napi-sys/napisys"
+)
+
+// method returns a Caller answering with s.
+func method(s string) *napisys.Caller {
+	return &napisys.Caller{Cb: func(env napisys.Env, info napisys.CallbackInfo) napisys.Value {
+		res, _ := napisys.CreateStringUtf8(env, s)
+		return res
+	}}
+}
+
+func init() {
+	// symbolMethods returns an object with a named method and two methods
+	// keyed by symbols, each answering with its own name.
+	register("symbolMethods", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		res, _ := napisys.CreateObject(env)
+		toStringTag, err := napisys.WellKnown(env, napisys.SymbolToStringTag)
+		if err != nil {
+			return nil, err
+		}
+		registered, status := napisys.SymbolFor(env, "primitives.describe")
+		if err := check(status); err != nil {
+			return nil, err
+		}
+		status = napisys.DefineProperties(env, res, []napisys.Property{
+			{Name: "name", Method: method("name")},
+			{Symbol: toStringTag, Method: method("toStringTag")},
+			{Symbol: registered, Method: method("describe")},
+		})
+		if err := check(status); err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
+	register("symbolFor", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		key, _ := args.String(0)
+		res, status := napisys.SymbolFor(env, key)
+		if err := check(status); err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
+}
@@ -0,0 +1,36 @@
+package main
+
+import "go-napi-sys/napisys"
+
+type taggedCounter struct{ N int }
+
+type taggedLabel struct{ S string }
+
+func init() {
+	register("wrapCounter", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		return nil, napisys.WrapObject(env, args.At(0), &taggedCounter{N: 41})
+	})
+	register("incCounter", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		c, err := napisys.UnwrapObject[*taggedCounter](env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		c.N++
+		return c.N, nil
+	})
+	register("newLabel", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		s, _ := args.StringOr(0, "")
+		return napisys.NewExternal(env, &taggedLabel{S: s})
+	})
+	register("readLabel", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		l, err := napisys.UnwrapExternal[*taggedLabel](env, args.At(0))
+		if err != nil {
+			return nil, err
+		}
+		return l.S, nil
+	})
+	register("readLabelAsCounter", func(env napisys.Env, args *napisys.Args) (interface{}, error) {
+		_, err := napisys.UnwrapExternal[*taggedCounter](env, args.At(0))
+		return nil, err
+	})
+}
@@ -0,0 +1,60 @@
+'use strict'
+
+const assert = require('assert');
+
+const tick = () => new Promise((resolve) => setTimeout(resolve, 20));
+
+module.exports = async function ({ aiterCount, aiterUnbounded, aiterCancelled, aiterReleased }) {
+  const it = aiterCount(0);
+  assert.strictEqual(it[Symbol.asyncIterator](), it);
+
+  const values = [];
+  for await (const v of aiterCount(3)) {
+    values.push(v);
+  }
+  assert.deepStrictEqual(values, [0, 1, 2]);
+
+  // Overlapping calls are served in order, including the ones past the end.
+  const overlapping = aiterCount(2);
+  const results = await Promise.all([1, 2, 3, 4, 5].map(() => overlapping.next()));
+  assert.deepStrictEqual(results, [
+    { value: 0, done: false },
+    { value: 1, done: false },
+    { value: undefined, done: true },
+    { value: undefined, done: true },
+    { value: undefined, done: true },
+  ]);
+  assert.deepStrictEqual(await overlapping.next(), { value: undefined, done: true });
+
+  const failing = aiterCount(1, 'cursor broke');
+  const settled = await Promise.allSettled([failing.next(), failing.next(), failing.next()]);
+  assert.deepStrictEqual(settled[0], { status: 'fulfilled', value: { value: 0, done: false } });
+  assert.strictEqual(settled[1].reason.message, 'cursor broke');
+  assert.deepStrictEqual(settled[2], { status: 'fulfilled', value: { value: undefined, done: true } });
+
+  // Breaking out of the loop closes done, which stops the producer.
+  for await (const v of aiterCount(100)) {
+    if (v === 1) {
+      break;
+    }
+  }
+  await tick();
+  assert.strictEqual(aiterCancelled(), 1);
+
+  // return() after the end of the iteration has nothing to cancel.
+  const finished = aiterCount(1);
+  assert.deepStrictEqual(await finished.next(), { value: 0, done: false });
+  assert.deepStrictEqual(await finished.next(), { value: undefined, done: true });
+  assert.deepStrictEqual(await finished.return('r'), { value: 'r', done: true });
+  await tick();
+  assert.strictEqual(aiterCancelled(), 1);
+
+  // Without a done channel, the channel is drained so that the producer is
+  // not left blocked on a send.
+  const unbounded = aiterUnbounded();
+  assert.deepStrictEqual(await unbounded.next(), { value: 0, done: false });
+  assert.deepStrictEqual(await unbounded.return(), { value: undefined, done: true });
+  assert.deepStrictEqual(await unbounded.next(), { value: undefined, done: true });
+  await tick();
+  assert.strictEqual(aiterReleased(), 1);
+};
@@ -0,0 +1,52 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ argsInfo, argsTyped, argsDefaults, argsThrow }) {
+  // Calls with as many arguments as are stored inline and more.
+  for (const n of [0, 1, 6, 7, 20]) {
+    const values = Array.from({ length: n }, (_, i) => ({ i }));
+    const self = {};
+    const res = argsInfo.apply(self, values);
+    assert.strictEqual(res.len, n);
+    assert.strictEqual(res.this, self);
+    assert.strictEqual(res.values.length, n);
+    res.values.forEach((value, i) => assert.strictEqual(value, values[i]));
+    assert.strictEqual(res.at[0], values[0]);
+    assert.strictEqual(res.at[1], values[n - 1]);
+    assert.strictEqual(res.at[2], undefined);
+    assert.strictEqual(res.at[3], undefined);
+    assert.deepStrictEqual(res.has, [n > 0, n > 1, false]);
+  }
+  assert.deepStrictEqual(argsInfo(undefined, null).has, [false, true, false]);
+  assert.strictEqual(argsInfo.call(argsInfo).this, argsInfo);
+
+  const fn = () => {};
+  const obj = {};
+  assert.deepStrictEqual(argsTyped(-3, 0.5, true, 'héllo', fn, obj, 42),
+    [-3, 0.5, true, 'héllo', fn, obj, 42]);
+  const typeError = (index, type, received) => ({
+    name: 'TypeError',
+    code: 'ERR_INVALID_ARG_TYPE',
+    message: `The argument at index ${index} must be of type ${type}. Received type ${received}`,
+  });
+  assert.throws(() => argsTyped('1'), typeError(0, 'number', 'string'));
+  assert.throws(() => argsTyped(1, null), typeError(1, 'number', 'null'));
+  assert.throws(() => argsTyped(1, 1, 1), typeError(2, 'boolean', 'number'));
+  assert.throws(() => argsTyped(1, 1, true, Symbol('s')), typeError(3, 'string', 'symbol'));
+  assert.throws(() => argsTyped(1, 1, true, '', {}), typeError(4, 'function', 'object'));
+  assert.throws(() => argsTyped(1, 1, true, '', fn, 1n), typeError(5, 'object', 'bigint'));
+  assert.throws(() => argsTyped(1, 1, true, '', fn, obj), typeError(6, 'number', 'undefined'));
+
+  const defaults = argsDefaults();
+  assert.deepStrictEqual(defaults.slice(0, 5), [7, 2.5, true, 'default', undefined]);
+  assert.deepStrictEqual(defaults[5], {});
+  assert.deepStrictEqual(argsDefaults(undefined, undefined, undefined, undefined, undefined, obj),
+    [7, 2.5, true, 'default', undefined, obj]);
+  assert.deepStrictEqual(argsDefaults(1, 2, false, 's', fn, obj), [1, 2, false, 's', fn, obj]);
+  assert.throws(() => argsDefaults(null), typeError(0, 'number', 'null'));
+  assert.throws(() => argsDefaults(1, 2, false, 's', fn, 'o'), typeError(5, 'object', 'string'));
+
+  assert.throws(() => argsThrow(),
+    { name: 'RangeError', message: 'out of range', code: 'ERR_OUT_OF_RANGE' });
+};
@@ -0,0 +1,40 @@
+'use strict'
+
+const assert = require('assert');
+const { Worker } = require('worker_threads');
+
+const napiOK = 0;
+const napiGenericFailure = 9;
+const napiCancelled = 11;
+
+module.exports = async function ({ poolRun, poolStatuses }) {
+  assert.deepStrictEqual(await poolRun(3, 5), [napiOK, napiOK, napiOK]);
+  assert.deepStrictEqual(await poolRun(3, 20, true), [napiOK, napiOK, napiCancelled]);
+
+  // Works still running when their environment shuts down complete as
+  // cancelled.
+  const before = poolStatuses().length;
+  const worker = new Worker(`
+    const { parentPort } = require('worker_threads');
+    const { poolRun } = require('bindings')('addon');
+    poolRun(2, 100);
+    parentPort.postMessage('queued');
+  `, { eval: true });
+  await new Promise((resolve) => worker.once('message', resolve));
+  await worker.terminate();
+  await new Promise((resolve) => setTimeout(resolve, 300));
+  assert.deepStrictEqual(poolStatuses().slice(before), [napiCancelled, napiCancelled]);
+
+  // Works cannot be queued again once their environment has shut down.
+  const requeued = new Worker(`
+    const { parentPort } = require('worker_threads');
+    const { poolRun } = require('bindings')('addon');
+    poolRun(2, 100, false, true);
+    parentPort.postMessage('queued');
+  `, { eval: true });
+  await new Promise((resolve) => requeued.once('message', resolve));
+  await requeued.terminate();
+  await new Promise((resolve) => setTimeout(resolve, 300));
+  assert.deepStrictEqual(poolStatuses().slice(before + 2),
+    [napiCancelled, napiGenericFailure, napiCancelled, napiGenericFailure]);
+};
@@ -0,0 +1,38 @@
+'use strict'
+
+const assert = require('assert');
+const { Worker } = require('worker_threads');
+
+module.exports = async function ({ awaitValue, awaitFunc, awaitTornDownOutcome }) {
+  for (const awaiter of [awaitValue, awaitFunc]) {
+    assert.deepStrictEqual(await awaiter(Promise.resolve({ a: [1, 'b'] })), { value: { a: [1, 'b'] } });
+    assert.deepStrictEqual(await awaiter(new Promise((resolve) => setTimeout(resolve, 10, 'later'))),
+      { value: 'later' });
+    assert.deepStrictEqual(await awaiter(Promise.reject(new TypeError('rejected'))),
+      { error: 'TypeError: rejected', jsError: true, name: 'TypeError' });
+    assert.deepStrictEqual(await awaiter(Promise.reject('a string')),
+      { error: 'Error: a string', jsError: true, name: '' });
+
+    // Values that are not thenables are delivered as they are.
+    assert.deepStrictEqual(await awaiter(42), { value: 42 });
+    assert.deepStrictEqual(await awaiter(undefined), { value: null });
+
+    // Thenables are followed, including when their then method throws.
+    assert.deepStrictEqual(await awaiter({ then(resolve) { resolve('thenable'); } }), { value: 'thenable' });
+    assert.deepStrictEqual(await awaiter({ then() { throw new RangeError('then failed'); } }),
+      { error: 'RangeError: then failed', jsError: true, name: 'RangeError' });
+  }
+
+  // A promise still pending when its environment shuts down is reported as
+  // such.
+  const worker = new Worker(`
+    const { parentPort } = require('worker_threads');
+    const { awaitForever } = require('bindings')('addon');
+    awaitForever(new Promise(() => {}));
+    parentPort.postMessage('awaiting');
+  `, { eval: true });
+  await new Promise((resolve) => worker.once('message', resolve));
+  await worker.terminate();
+  assert.deepStrictEqual(awaitTornDownOutcome(),
+    { error: 'napisys: environment has been shut down', jsError: false });
+};
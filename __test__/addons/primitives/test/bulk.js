@@ -0,0 +1,42 @@
+'use strict'
+
+const assert = require('assert');
+
+const napiInvalidArg = 1;
+const napiArrayExpected = 8;
+
+const range = (n, f) => Array.from({ length: n }, (_, i) => f(i));
+const bulkString = (i) => 'é'.repeat(i % 4) + i;
+
+module.exports = async function (addon) {
+  const {
+    bulkFloat64s, bulkInt32s, bulkStrings, bulkValues, bulkProperties, bulkElements,
+  } = addon;
+
+  // Empty, small and large arrays: more elements than any buffer sized for
+  // the common case.
+  for (const n of [0, 1, 16, 17, 1000]) {
+    assert.deepStrictEqual(bulkFloat64s(n), range(n, (i) => i / 2));
+    assert.deepStrictEqual(bulkInt32s(n), range(n, (i) => i - Math.trunc(n / 2)));
+    assert.deepStrictEqual(bulkStrings(n), range(n, bulkString));
+    assert.deepStrictEqual(bulkValues(n), range(n, (i) => i));
+    assert.deepStrictEqual(bulkProperties(n),
+      Object.fromEntries(range(n, (i) => [bulkString(i), i])));
+
+    const array = range(n, (i) => (i % 2 ? { i } : `s${i}`));
+    const copy = bulkElements(array);
+    assert.deepStrictEqual(copy, array);
+    copy.forEach((element, i) => assert.strictEqual(element, array[i]));
+  }
+
+  assert.strictEqual(bulkProperties(3, true), napiInvalidArg);
+
+  // Holes read as undefined.
+  assert.deepStrictEqual(bulkElements([1, , 3, , ]), [1, undefined, 3, undefined]);
+  assert.deepStrictEqual(bulkElements(new Array(20)), range(20, () => undefined));
+
+  // Anything but an Array is rejected, array-likes included.
+  for (const value of [{ length: 2, 0: 'a', 1: 'b' }, 'ab', 42, null, new Uint8Array(2)]) {
+    assert.strictEqual(bulkElements(value), napiArrayExpected);
+  }
+};
@@ -0,0 +1,61 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ callFunction, callGoValues, newInstance, callMethod, toValueNested, toValueCyclicMap }) {
+  // Any number of arguments, including none.
+  assert.deepStrictEqual(callFunction(function () { return [this, ...arguments]; }),
+    { value: [undefined] });
+  const self = {};
+  assert.deepStrictEqual(callFunction(function () { return [this, ...arguments]; }, self, 1, 'a', null),
+    { value: [self, 1, 'a', null] });
+  assert.deepStrictEqual(callFunction((...args) => args.length, undefined, ...new Array(20).fill(0)),
+    { value: 20 });
+  assert.deepStrictEqual(callGoValues((...args) => args),
+    { value: ['text', 1.5, [1, 2], { ok: true }, { next: null }, null] });
+
+  // A throwing callee is reported as a *JSError and leaves no exception
+  // pending.
+  assert.deepStrictEqual(callFunction(() => { throw new RangeError('out of range'); }),
+    { error: 'RangeError: out of range', jsError: true, name: 'RangeError', code: '' });
+  assert.deepStrictEqual(callFunction(() => { throw Object.assign(new Error('coded'), { code: 'E_CODE' }); }),
+    { error: 'Error [E_CODE]: coded', jsError: true, name: 'Error', code: 'E_CODE' });
+  assert.deepStrictEqual(callFunction(() => { throw 'a string'; }),
+    { error: 'Error: a string', jsError: true, name: '', code: '' });
+
+  // New, with and without arguments.
+  class Point {
+    constructor(x, y) {
+      this.x = x;
+      this.y = y;
+    }
+  }
+  const point = newInstance(Point, 1, 2).value;
+  assert.ok(point instanceof Point);
+  assert.deepStrictEqual({ ...point }, { x: 1, y: 2 });
+  assert.deepStrictEqual({ ...newInstance(Point).value }, { x: undefined, y: undefined });
+  assert.strictEqual(newInstance(Date, 0).value.getTime(), 0);
+  assert.deepStrictEqual(newInstance(function () { throw new TypeError('no'); }),
+    { error: 'TypeError: no', jsError: true, name: 'TypeError', code: '' });
+
+  // CallMethod binds this to the object.
+  const counter = { n: 1, run(step = 1) { this.n += step; return this.n; } };
+  assert.deepStrictEqual(callMethod(counter, false), { value: 2 });
+  assert.deepStrictEqual(callMethod(counter, false, 10), { value: 12 });
+  assert.deepStrictEqual(callMethod(counter, true),
+    { error: 'TypeError: missing is not a function', jsError: true, name: 'TypeError', code: '' });
+
+  // ToValue reports cycles and excessive nesting as errors.
+  let nested = toValueNested(500).value;
+  let depth = 0;
+  for (; nested !== null; nested = nested.next) {
+    depth++;
+  }
+  assert.strictEqual(depth, 500);
+  assert.deepStrictEqual(toValueNested(2000),
+    { error: 'napisys: value nested more than 1000 levels deep', jsError: false });
+  assert.deepStrictEqual(toValueNested(3, true),
+    { error: 'napisys: cannot convert *main.callNode to a JavaScript value: encountered a cycle', jsError: false });
+  assert.deepStrictEqual(toValueCyclicMap(),
+    { error: 'napisys: cannot convert map[string]interface {} to a JavaScript value: encountered a cycle', jsError: false });
+};
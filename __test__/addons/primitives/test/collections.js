@@ -0,0 +1,16 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ roundTrip, collections }) {
+  const map = new Map([['a', 1], [2, new Set(['x'])], [true, { nested: new Map([['k', [1, 2]]]) }]]);
+  assert.deepStrictEqual(roundTrip(map), map);
+  assert.deepStrictEqual(roundTrip({ list: [new Set([1, 2])], plain: { a: 'b' } }),
+    { list: [new Set([1, 2])], plain: { a: 'b' } });
+  assert.throws(() => roundTrip(new Map([[{}, 1]])), /cannot use a JavaScript object as the key of a Go map/);
+
+  const { byID, flags, sizes } = collections();
+  assert.deepStrictEqual(byID, new Map([[1n, 'one'], [2n ** 53n + 1n, 'big']]));
+  assert.deepStrictEqual(flags, new Set(['a']));
+  assert.deepStrictEqual(sizes, new Map([[0.5, 1]]));
+};
@@ -0,0 +1,58 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ writeDataView, readDataView, dataViewBounds }) {
+  const dv = writeDataView();
+  assert(dv instanceof DataView);
+  assert.strictEqual(dv.byteLength, 32);
+  assert.strictEqual(dv.getUint16(0), 0x0102);
+  assert.strictEqual(dv.getInt32(2, true), -5);
+  assert.strictEqual(dv.getFloat64(6), 3.25);
+  assert.strictEqual(dv.getBigInt64(14, true), -7n);
+  assert.strictEqual(dv.getFloat32(28, true), 1.5);
+  // 300 takes two varint bytes, -2 zig-zag encodes to a single 3.
+  assert.deepStrictEqual([...new Uint8Array(dv.buffer, 22, 4)], [0xac, 0x02, 0x03, 0xff]);
+
+  // A view starting inside a larger buffer is read from its own offset.
+  const ab = new ArrayBuffer(64);
+  new Uint8Array(ab).set(new Uint8Array(dv.buffer), 5);
+  const inner = new DataView(ab, 5, 32);
+  assert.deepStrictEqual(readDataView(inner), [32, 0x0102, 0x0201, -5, 3.25, -7, 300, 2, -2, 1, -1, 1.5]);
+
+  const outOfRange = {
+    name: 'RangeError',
+    code: 'ERR_OUT_OF_RANGE',
+    message: 'Offset is outside the bounds of the DataView',
+  };
+  assert.strictEqual(dataViewBounds(inner, 'uint8', 31), 0x3f);
+  assert.throws(() => dataViewBounds(inner, 'uint8', 32), outOfRange);
+  assert.throws(() => dataViewBounds(inner, 'uint8', -1), outOfRange);
+  assert.throws(() => dataViewBounds(inner, 'uint32', 29), outOfRange);
+  assert.throws(() => dataViewBounds(inner, 'setFloat64', 25), outOfRange);
+  assert.throws(() => dataViewBounds(inner, 'uvarint', 32), outOfRange);
+  // 1 << 40 needs six varint bytes: nothing is written when they do not fit.
+  const before = [...new Uint8Array(ab)];
+  assert.throws(() => dataViewBounds(inner, 'setUvarint', 27), outOfRange);
+  assert.deepStrictEqual([...new Uint8Array(ab)], before);
+  dataViewBounds(inner, 'setUvarint', 26);
+  assert.strictEqual(new Uint8Array(ab)[5 + 31], 0x20);
+
+  // A varint running off the end is out of range, one of more than 64 bits
+  // overflows.
+  const truncated = new DataView(new Uint8Array([0x80, 0x80]).buffer);
+  assert.throws(() => dataViewBounds(truncated, 'uvarint', 0), outOfRange);
+  const long = new DataView(new Uint8Array(11).fill(0xff).buffer);
+  assert.throws(() => dataViewBounds(long, 'uvarint', 0), {
+    name: 'RangeError',
+    code: 'ERR_OUT_OF_RANGE',
+    message: 'Varint overflows a 64-bit integer',
+  });
+
+  assert.throws(() => dataViewBounds(new DataView(new ArrayBuffer(0)), 'uint8', 0), outOfRange);
+  assert.throws(() => readDataView(new Uint8Array(2)), {
+    name: 'TypeError',
+    code: 'ERR_INVALID_ARG_TYPE',
+    message: 'The value must be a DataView',
+  });
+};
@@ -0,0 +1,82 @@
+'use strict'
+
+const assert = require('assert');
+const EventEmitter = require('events');
+const { Worker } = require('worker_threads');
+
+function ticks(emitter) {
+  const received = [];
+  emitter.on('tick', (i, o) => received.push([i, o.square]));
+  return new Promise((resolve) => emitter.on('end', () => {
+    emitter.removeAllListeners();
+    resolve(received);
+  }));
+}
+
+module.exports = async function ({ emitterTicks, emitterIdle }) {
+  const emitter = emitterTicks(3);
+  assert.ok(emitter instanceof EventEmitter);
+  assert.deepStrictEqual(await ticks(emitter), [[0, 0], [1, 1], [2, 4]]);
+
+  // A listener throwing is reported as an 'error' event.
+  const failing = emitterTicks(2);
+  const errors = [];
+  failing.on('error', (err) => errors.push(err.message));
+  failing.on('tick', (i) => {
+    if (i === 0) {
+      throw new Error('listener failed');
+    }
+  });
+  await new Promise((resolve) => failing.on('end', resolve));
+  failing.removeAllListeners();
+  assert.deepStrictEqual(errors, ['listener failed']);
+
+  // Tracking listeners adds none, and leaves the own properties alone.
+  const fresh = emitterIdle();
+  assert.deepStrictEqual(fresh.eventNames(), []);
+  assert.strictEqual(fresh.listenerCount('newListener'), 0);
+  assert.strictEqual(fresh.listenerCount('removeListener'), 0);
+  assert.ok(!Object.keys(fresh).some((key) => ['on', 'off', 'once'].includes(key)));
+  const seen = [];
+  fresh.on('newListener', (name) => seen.push(name));
+  fresh.on('tick', () => {});
+  assert.deepStrictEqual(seen, ['tick']);
+  assert.deepStrictEqual(fresh.eventNames(), ['newListener', 'tick']);
+  fresh.removeAllListeners();
+
+  // Emitters nobody listens to anymore must not keep the process alive,
+  // which index.js checks once the tests are done, whatever the way their
+  // listeners went away.
+  const idle = emitterIdle();
+  const listener = () => {};
+  idle.on('tick', listener);
+  idle.on('tick', listener);
+  idle.off('tick', listener);
+  idle.off('tick', listener);
+  const cleared = emitterIdle();
+  cleared.on('tick', listener).prependListener('end', listener).once(Symbol('s'), listener);
+  assert.strictEqual(cleared.removeAllListeners(), cleared);
+  const onced = emitterTicks(1);
+  await new Promise((resolve) => onced.once('end', resolve));
+
+  // Without process.getBuiltinModule, as before Node.js 20.16, the events
+  // module is loaded with createRequire, from a CommonJS main module or from
+  // code evaluated by a Worker.
+  const getBuiltinModule = process.getBuiltinModule;
+  process.getBuiltinModule = undefined;
+  try {
+    assert.deepStrictEqual(await ticks(emitterTicks(1)), [[0, 0]]);
+  } finally {
+    process.getBuiltinModule = getBuiltinModule;
+  }
+  const worker = new Worker(`
+    const { parentPort } = require('worker_threads');
+    process.getBuiltinModule = undefined;
+    const emitter = require('bindings')('addon').emitterTicks(2);
+    const received = [];
+    emitter.on('tick', (i) => received.push(i));
+    emitter.once('end', () => parentPort.postMessage(received));
+  `, { eval: true });
+  assert.deepStrictEqual(await new Promise((resolve) => worker.once('message', resolve)), [0, 1]);
+  await worker.terminate();
+};
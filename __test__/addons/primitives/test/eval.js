@@ -0,0 +1,39 @@
+'use strict'
+
+const assert = require('assert');
+const os = require('os');
+const path = require('path');
+const url = require('url');
+
+module.exports = async function (addon) {
+  const { eval: evaluate, require: load, moduleFileName } = addon;
+
+  assert.strictEqual(evaluate('1 + 2'), 3);
+  // Scripts run in the global scope, not in the caller's.
+  assert.strictEqual(evaluate('var evalGlobal = 5; evalGlobal * 2'), 10);
+  assert.strictEqual(globalThis.evalGlobal, 5);
+  assert.strictEqual(evaluate('typeof assert'), 'undefined');
+  assert.throws(() => evaluate('{'), { name: 'SyntaxError' });
+  assert.throws(() => evaluate('throw new RangeError("r")'), { name: 'RangeError', message: 'r' });
+  assert.throws(() => evaluate('undefinedThing'), { name: 'ReferenceError' });
+
+  const addonFile = url.fileURLToPath(moduleFileName());
+  assert.strictEqual(path.basename(addonFile), 'addon.node');
+
+  // Relative ids resolve against the addon, whatever the working directory.
+  const cwd = process.cwd();
+  process.chdir(os.tmpdir());
+  try {
+    const fixture = load('../../test/fixtures/answer.js');
+    assert.strictEqual(fixture.answer, 42);
+    assert.strictEqual(fixture.filename, path.resolve(path.dirname(addonFile), '../../test/fixtures/answer.js'));
+    assert.strictEqual(load('../../test/fixtures/answer'), fixture);
+    assert.throws(() => load('./missing'), { code: 'MODULE_NOT_FOUND' });
+  } finally {
+    process.chdir(cwd);
+  }
+
+  assert.strictEqual(load('node:path'), path);
+  assert.strictEqual(load('os'), os);
+  assert.strictEqual(typeof load('worker_threads').Worker, 'function');
+};
@@ -0,0 +1,21 @@
+'use strict'
+
+const assert = require('assert');
+const { Worker } = require('worker_threads');
+
+module.exports = async function ({ invokeInline, invokeFromGoroutine, invokeThrow, executorRemember, executorPostLater }) {
+  assert.deepStrictEqual(invokeInline(), { answer: 42 });
+  assert.strictEqual(await invokeFromGoroutine(5), 0 + 1 + 2 + 3 + 4);
+  assert.strictEqual(invokeThrow(), 'Error: thrown on the main thread');
+
+  // Once its environment has shut down, work cannot be posted anymore and
+  // the executor is dropped.
+  const worker = new Worker(`
+    const { parentPort } = require('worker_threads');
+    require('bindings')('addon').executorRemember();
+    parentPort.postMessage('started');
+  `, { eval: true });
+  await new Promise((resolve) => worker.once('message', resolve));
+  await worker.terminate();
+  assert.strictEqual(executorPostLater(), 'napisys: no executor started for environment');
+};
@@ -0,0 +1,3 @@
+'use strict'
+
+module.exports = { answer: 42, filename: __filename };
@@ -0,0 +1,52 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function (addon) {
+  const { frozenConfig, frozenCopy, frozenPoint, frozenNested, frozenCollections, freezeValue, deepFreeze } = addon;
+
+  const cfg = frozenConfig();
+  assert.strictEqual(frozenConfig(), cfg);
+  assert.deepStrictEqual(cfg.limits, { a: 1 });
+  for (const v of [cfg, cfg.limits, cfg.tags, cfg.nested, cfg.nested.n]) {
+    assert(Object.isFrozen(v));
+  }
+  // TypedArrays cannot be frozen.
+  assert(!Object.isFrozen(cfg.blob));
+  assert.throws(() => { cfg.limits.a = 2; }, TypeError);
+
+  // Another Go value with the same content is another snapshot, while
+  // comparable values are keyed by their content.
+  assert.notStrictEqual(frozenCopy(), cfg);
+  assert.strictEqual(frozenPoint(), frozenPoint());
+  assert.deepStrictEqual(frozenPoint(), { X: 1, Y: 2 });
+
+  const nested = frozenNested();
+  assert(!Object.isFrozen(nested));
+  assert.strictEqual(nested.frozen, cfg);
+  assert(!Object.isFrozen(nested.mutable));
+
+  const cyclic = { x: {} };
+  cyclic.x.back = cyclic;
+  assert.strictEqual(freezeValue(cyclic), cyclic);
+  assert(Object.isFrozen(cyclic) && Object.isFrozen(cyclic.x));
+
+  const fn = function () {};
+  fn.meta = { a: [1] };
+  const obj = { fn, buf: new Uint8Array(2), list: [{ k: 1 }] };
+  assert.strictEqual(deepFreeze(obj), obj);
+  for (const v of [obj, fn, fn.meta, fn.meta.a, obj.list, obj.list[0]]) {
+    assert(Object.isFrozen(v));
+  }
+  obj.buf[0] = 7;
+  assert.strictEqual(obj.buf[0], 7);
+  assert.strictEqual(deepFreeze(42), 42);
+
+  // Maps and Sets cannot be frozen, whether they come from Go or not.
+  const collection = /cannot freeze a Map or a Set/;
+  for (const kind of ['map', 'set', 'nested']) {
+    assert.throws(() => frozenCollections(kind), collection);
+  }
+  assert.throws(() => deepFreeze({ list: [new Map([['a', 1]])] }), collection);
+  assert.throws(() => freezeValue(new Set([1])), collection);
+};
@@ -0,0 +1,49 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ newFS }) {
+  const copying = newFS();
+  const buffer = copying.readFile('/hello.txt');
+  assert.ok(Buffer.isBuffer(buffer));
+  assert.strictEqual(buffer.toString(), 'hello, world\n');
+  buffer.write('J');
+  assert.strictEqual(copying.readFile('hello.txt').toString(), 'hello, world\n');
+
+  assert.deepStrictEqual(copying.readdir('.'), ['dir', 'hello.txt']);
+  assert.deepStrictEqual(copying.readdir('dir/..'), ['dir', 'hello.txt']);
+  const stat = copying.stat('dir/a.txt');
+  assert.strictEqual(stat.size, 2);
+  assert.strictEqual(stat.isFile, true);
+  assert.strictEqual(copying.stat('dir').isDirectory, true);
+  assert.strictEqual(copying.exists('missing'), false);
+  assert.throws(() => copying.readFile('missing'), { code: 'ENOENT' });
+  assert.throws(() => copying.readFile('dir'), { code: 'EISDIR' });
+  for (const method of ['readFile', 'readdir', 'stat', 'exists']) {
+    assert.throws(() => copying[method](1), {
+      name: 'TypeError',
+      code: 'ERR_INVALID_ARG_TYPE',
+      message: 'The "path" argument must be of type string. Received type number',
+    });
+  }
+  assert.throws(() => copying.readFile(), { name: 'TypeError', code: 'ERR_INVALID_ARG_TYPE' });
+
+  // Files of an embed.FS are read once, and every Buffer of a file shares
+  // that content.
+  const embedded = newFS(true);
+  const first = embedded.readFile('testdata/hello.txt');
+  const second = newFS(true).readFile('/testdata/hello.txt');
+  assert.strictEqual(first.toString(), 'hello, world\n');
+  first[0] = 'J'.charCodeAt(0);
+  assert.strictEqual(second.toString(), 'Jello, world\n');
+  first[0] = 'h'.charCodeAt(0);
+  assert.throws(() => embedded.readFile('testdata/missing'), { code: 'ENOENT' });
+
+  // Collecting the Buffers leaves the content in place.
+  for (let i = 0; i < 1000; i++) {
+    embedded.readFile('testdata/dir/a.txt');
+  }
+  global.gc && global.gc();
+  await new Promise((resolve) => setImmediate(resolve));
+  assert.strictEqual(embedded.readFile('testdata/dir/a.txt').toString(), 'a\n');
+};
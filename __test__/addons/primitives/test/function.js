@@ -0,0 +1,41 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({
+  fnEcho, fnFirst, fnLen, fnError, fnPanic, fnPending, fnUnconvertible,
+  fnAllocsBegin, fnAllocsEnd, fnLastLen,
+}) {
+  assert.strictEqual(fnEcho.name, 'fnEcho');
+  const self = {};
+  const res = fnEcho.call(self, 1, 'two', null);
+  assert.strictEqual(res.this, self);
+  assert.deepStrictEqual(res.args, [1, 'two', null]);
+  assert.deepStrictEqual(fnEcho(...new Array(10).fill(0)).args, new Array(10).fill(0));
+
+  assert.throws(() => fnError(), { name: 'Error', message: 'plain failure' });
+  assert.throws(() => fnError(true),
+    { name: 'RangeError', message: 'coded failure', code: 'ERR_CODED' });
+  assert.throws(() => fnPanic(), { message: 'napisys: panic in Go function: boom' });
+  assert.throws(() => fnPending(), { name: 'TypeError', message: 'left pending', code: 'ERR_PENDING' });
+  assert.throws(() => fnUnconvertible(), /cannot convert chan int/);
+
+  assert.strictEqual(fnFirst(self), self);
+  assert.strictEqual(fnFirst(), undefined);
+
+  // Calling a Go function allocates nothing on the Go heap by itself, as long
+  // as its arguments are kept inline.
+  const calls = 10000;
+  for (const args of [[], [self, 2, 3], new Array(6).fill(self)]) {
+    for (let i = 0; i < 100; i++) {
+      fnLen(...args);
+    }
+    fnAllocsBegin();
+    for (let i = 0; i < calls; i++) {
+      fnLen(...args);
+    }
+    const allocs = fnAllocsEnd();
+    assert.ok(allocs < calls / 100, `${allocs} allocations for ${calls} calls with ${args.length} arguments`);
+    assert.strictEqual(fnLastLen(), args.length);
+  }
+};
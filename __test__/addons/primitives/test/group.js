@@ -0,0 +1,29 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ group, groupCancelled }) {
+  // Results are in the order of the tasks, not of their completion.
+  assert.deepStrictEqual(await group('all'), ['first', 2, [1, 2]]);
+  await assert.rejects(group('allFailing'), { message: 'bad' });
+
+  const settled = await group('allSettled');
+  assert.deepStrictEqual(settled[0], { status: 'fulfilled', value: 'ok' });
+  assert.strictEqual(settled[1].status, 'rejected');
+  assert.strictEqual(settled[1].reason.message, 'bad');
+  assert.strictEqual(settled[2].reason.message, 'napisys: panic in task: boom');
+
+  assert.strictEqual(await group('race'), 'fast');
+  assert.strictEqual(await group('any'), 'later');
+  await assert.rejects(group('anyFailing'), (err) => {
+    assert.ok(err instanceof AggregateError);
+    assert.deepStrictEqual(err.errors.map(String), ['Error: bad', 'TypeError: t']);
+    return true;
+  });
+  assert.deepStrictEqual(await group('empty'), []);
+
+  // The tasks still running once the outcome is known are cancelled: one
+  // for allFailing, two for race and one for any.
+  await new Promise((resolve) => setTimeout(resolve, 50));
+  assert.strictEqual(groupCancelled(), 4);
+};
@@ -0,0 +1,53 @@
+'use strict'
+
+const assert = require('assert');
+const http = require('http');
+
+function request(port, path, options = {}, body) {
+  return new Promise((resolve, reject) => {
+    const req = http.request({ host: '127.0.0.1', port, path, ...options }, (res) => {
+      const chunks = [];
+      res.on('data', (chunk) => chunks.push(chunk));
+      res.on('end', () => resolve({
+        status: res.statusCode,
+        headers: res.headers,
+        body: Buffer.concat(chunks).toString(),
+      }));
+      res.on('error', reject);
+    });
+    req.on('error', reject);
+    req.end(body);
+  });
+}
+
+module.exports = async function ({ httpListener, httpLastError }) {
+  const server = http.createServer(httpListener());
+  await new Promise((resolve) => server.listen(0, '127.0.0.1', resolve));
+  const { port } = server.address();
+  try {
+    let res = await request(port, '/hello?q=1', { headers: { 'X-Test': 'yes' } });
+    assert.strictEqual(res.status, 200);
+    assert.strictEqual(res.headers['content-type'], 'text/plain; charset=utf-8');
+    assert.strictEqual(res.headers['x-multi'], 'a, b');
+    assert.strictEqual(res.body, 'hello GET /hello q=1 x-test=yes');
+
+    const payload = 'x'.repeat(200000);
+    res = await request(port, '/echo', { method: 'POST' }, payload);
+    assert.strictEqual(res.status, 201);
+    assert.strictEqual(res.headers['content-type'], 'application/octet-stream');
+    assert.strictEqual(res.body, `${payload}|200000 <nil> 200000`);
+
+    res = await request(port, '/panic');
+    assert.strictEqual(res.status, 500);
+    assert.strictEqual(res.body, 'Internal Server Error\n');
+
+    res = await request(port, '/missing');
+    assert.strictEqual(res.status, 404);
+
+    res = await request(port, '/bad-header');
+    assert.strictEqual(res.body, '');
+    assert.match(httpLastError(), /Invalid character in header content/);
+  } finally {
+    await new Promise((resolve) => server.close(resolve));
+  }
+};
@@ -0,0 +1,82 @@
+'use strict'
+
+const assert = require('assert');
+const { Readable } = require('stream');
+
+module.exports = async function ({ iterate, iterateAsync }) {
+  let closed = 0;
+  function* numbers() {
+    try {
+      yield 1;
+      yield 2;
+      yield 3;
+    } finally {
+      closed++;
+    }
+  }
+  async function* ticks() {
+    try {
+      for (let i = 0; ; i++) {
+        await new Promise((resolve) => setTimeout(resolve, 2));
+        yield { i };
+      }
+    } finally {
+      closed++;
+    }
+  }
+  async function* failing() {
+    yield 'a';
+    throw new RangeError('boom');
+  }
+
+  assert.deepStrictEqual(iterate([1, 'a', null]), [1, 'a', null]);
+  assert.deepStrictEqual(iterate(new Map([['k', 1]])), [['k', 1]]);
+  assert.deepStrictEqual(iterate('héllo'), ['h', 'é', 'l', 'l', 'o']);
+  // Stopping early closes the iterator.
+  assert.deepStrictEqual(iterate(numbers(), 2), [1, 2]);
+  assert.strictEqual(closed, 1);
+  assert.deepStrictEqual(iterate(function* () {
+    yield 1;
+    throw new Error('inside');
+  }()), [1, 'error: Error: inside']);
+  assert.match(iterate(42)[0], /^error: TypeError/);
+
+  assert.deepStrictEqual(await iterateAsync(ticks(), 3), [{ i: 0 }, { i: 1 }, { i: 2 }]);
+  assert.strictEqual(closed, 2);
+  assert.deepStrictEqual(await iterateAsync(Readable.from([Buffer.from('x'), Buffer.from('y')])),
+    ['bytes: x', 'bytes: y']);
+  assert.deepStrictEqual(await iterateAsync(failing()), ['a', 'error: RangeError: boom']);
+  assert.deepStrictEqual(await iterateAsync([5, 6]), [5, 6]);
+
+  // A value that cannot be converted ends the iteration and closes the
+  // iterator, which a rejection of next() does not need.
+  async function* symbols() {
+    try {
+      yield 'ok';
+      yield Symbol('unconvertible');
+      yield 'never';
+    } finally {
+      closed++;
+    }
+  }
+  assert.deepStrictEqual(await iterateAsync(symbols()),
+    ['ok', 'error: napisys: cannot convert a JavaScript symbol to a Go value']);
+  await new Promise((resolve) => setTimeout(resolve, 20));
+  assert.strictEqual(closed, 3);
+  let returned = 0;
+  const rejecting = {
+    [Symbol.asyncIterator]() {
+      return this;
+    },
+    next() {
+      return Promise.reject(new TypeError('next failed'));
+    },
+    return() {
+      returned++;
+      return Promise.resolve({ done: true });
+    },
+  };
+  assert.deepStrictEqual(await iterateAsync(rejecting), ['error: TypeError: next failed']);
+  await new Promise((resolve) => setTimeout(resolve, 20));
+  assert.strictEqual(returned, 0);
+};
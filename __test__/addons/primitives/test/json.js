@@ -0,0 +1,19 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ parseJSON, stringifyJSON, rawJSON }) {
+  assert.deepStrictEqual(parseJSON('{"a":1,"b":[true,"é"]}'), { a: 1, b: [true, 'é'] });
+  assert.strictEqual(stringifyJSON({ x: [1, 'ü', null], y: undefined }), '{"x":[1,"ü",null]}');
+  assert.throws(() => stringifyJSON(undefined), { message: 'napisys: value cannot be represented as JSON' });
+  assert.throws(() => stringifyJSON(() => 1), { message: 'napisys: value cannot be represented as JSON' });
+  const cyclic = {};
+  cyclic.self = cyclic;
+  assert.throws(() => stringifyJSON(cyclic), TypeError);
+  assert.throws(() => parseJSON('{bad'), { name: 'SyntaxError' });
+  assert.throws(() => parseJSON(''), { name: 'SyntaxError' });
+  assert.deepStrictEqual(rawJSON(), { name: 'x', extra: { a: [1, 2, { b: null }] } });
+
+  const big = JSON.stringify(Array.from({ length: 10000 }, (_, i) => ({ i, s: `v${i}` })));
+  assert.strictEqual(stringifyJSON(parseJSON(big)), big);
+};
@@ -0,0 +1,23 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ writableKeys, entries }) {
+  const object = { b: 1, 2: 'two', [Symbol('s')]: 3 };
+  Object.defineProperty(object, 'fixed', { value: 4, writable: false, enumerable: true });
+  assert.deepStrictEqual(writableKeys(object), ['2', 'b']);
+  const child = Object.create({ inherited: 1 }, { own: { value: 2, writable: true } });
+  assert.deepStrictEqual(writableKeys(child), ['own']);
+  assert.ok(writableKeys(child, true).includes('inherited'));
+  assert.ok(writableKeys(child, true).includes('hasOwnProperty'));
+  assert.deepStrictEqual(entries(object), [[['2', 'two'], ['b', 1], ['fixed', 4]], null]);
+
+  // A failure ends the entries and is left pending.
+  const throwing = { a: 1, get b() { throw new Error('getter failed'); }, c: 3 };
+  const [read, err] = entries(throwing);
+  assert.deepStrictEqual(read, [['a', 1]]);
+  assert.strictEqual(err.message, 'getter failed');
+  const [values, exception] = entries(null);
+  assert.deepStrictEqual(values, []);
+  assert.ok(exception instanceof Error);
+};
@@ -0,0 +1,57 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ tickClass, newTicks, loadTick, viewTicks, layoutErrors }) {
+  for (const order of ['le', 'be']) {
+    const Tick = tickClass(order);
+    assert.strictEqual(Tick.name, 'Tick');
+    assert.strictEqual(Tick.BYTES_PER_RECORD, 35);
+    const buf = newTicks(order, 4);
+    assert.strictEqual(buf.byteLength, 4 * 35);
+
+    // Go to JavaScript.
+    const t = Tick.at(buf, 1);
+    assert.strictEqual(t.id, 7);
+    assert.strictEqual(t.live, true);
+    assert.strictEqual(t.price, 1.25);
+    assert.strictEqual(t.volume, -(1n << 40n));
+    assert.strictEqual(Buffer.from(t.sym).toString(), 'ABCD');
+    assert.deepStrictEqual(t.hist, [-1, 2, 3]);
+    assert.strictEqual(t.Secret, undefined);
+    assert.strictEqual(new DataView(buf).getUint32(35, order === 'le'), 7);
+
+    // JavaScript to Go.
+    const u = Tick.at(buf, 2);
+    u.id = 99;
+    u.live = true;
+    u.price = -2.5;
+    u.volume = 5n;
+    u.sym = [0x78, 0x79];
+    u.hist = [4, -5, 6];
+    assert.deepStrictEqual(loadTick(2), [99, true, -2.5, 5, 'xy\0\0', [4, -5, 6], 0]);
+    // The byte array is a view of the record.
+    t.sym[0] = 0x5a;
+    assert.deepStrictEqual(loadTick(1), [7, true, 1.25, -(2 ** 40), 'ZBCD', [-1, 2, 3], 9]);
+    assert.deepStrictEqual(loadTick(0), [0, false, 0, 0, '\0\0\0\0', [0, 0, 0], 0]);
+
+    assert.throws(() => loadTick(4), { name: 'RangeError', message: 'Record index 4 is out of range' });
+    assert.throws(() => Tick.at(buf, 4), RangeError);
+
+    // Go views records in an ArrayBuffer allocated by JavaScript.
+    const ab = new ArrayBuffer(Tick.BYTES_PER_RECORD * 2 + 3);
+    new Tick(ab).id = 5;
+    new Tick(ab, Tick.BYTES_PER_RECORD).id = 6;
+    assert.deepStrictEqual(viewTicks(order, ab, 0), [5, 6]);
+    assert.deepStrictEqual(viewTicks(order, ab, Tick.BYTES_PER_RECORD), [6]);
+    assert.deepStrictEqual(viewTicks(order, ab, ab.byteLength), []);
+    assert.throws(() => viewTicks(order, ab, ab.byteLength + 1), { code: 'ERR_OUT_OF_RANGE' });
+    assert.throws(() => viewTicks(order, new Uint8Array(4), 0), { message: 'The value must be an ArrayBuffer' });
+  }
+  assert.deepStrictEqual(layoutErrors(), [
+    'napisys: cannot lay out field N of type int',
+    'napisys: cannot lay out field C named "constructor", which is reserved in a JavaScript class',
+    'napisys: cannot lay out field P named "__proto__", which is reserved in a JavaScript class',
+    'napisys: cannot lay out field Key named "ID", the name of field ID',
+  ]);
+};
@@ -0,0 +1,31 @@
+'use strict'
+
+const assert = require('assert');
+const { Worker } = require('worker_threads');
+
+module.exports = async function ({ promiseSettle, promiseLate }) {
+  assert.deepStrictEqual(await promiseSettle('twice'), { a: 1 });
+  assert.strictEqual(promiseLate(), 'napisys: promise already settled');
+
+  await assert.rejects(promiseSettle('reject'), (err) => {
+    assert.ok(err instanceof TypeError);
+    assert.strictEqual(err.code, 'ERR_TEST');
+    assert.strictEqual(err.message, 'bad');
+    return true;
+  });
+
+  assert.strictEqual(await promiseSettle('sync'), 42);
+  assert.strictEqual(promiseLate(), 'napisys: promise already settled');
+
+  await assert.rejects(promiseSettle('unconvertible'), /cannot convert/);
+  await assert.rejects(promiseSettle('abandon'),
+    { message: 'napisys: promise abandoned without being settled' });
+
+  // A worker exiting with a pending promise does not bring the process down.
+  const worker = new Worker(`
+    const { promiseSettle } = require('bindings')('addon');
+    promiseSettle('keep');
+    setTimeout(() => process.exit(0), 20);
+  `, { eval: true });
+  assert.strictEqual(await new Promise((resolve) => worker.on('exit', resolve)), 0);
+};
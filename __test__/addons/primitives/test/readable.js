@@ -0,0 +1,40 @@
+'use strict'
+
+const assert = require('assert');
+const { Readable } = require('stream');
+
+module.exports = async function ({ readable, readableStats }) {
+  const big = readable('big');
+  assert.ok(big instanceof Readable);
+  let total = 0;
+  for await (const chunk of big) {
+    total += chunk.length;
+  }
+  assert.strictEqual(total, 1000000);
+  assert.strictEqual(readableStats().closes, 1);
+
+  // Once paused, the stream stops reading from Go when its buffer is full.
+  const endless = readable('endless');
+  await new Promise((resolve) => endless.once('data', () => {
+    endless.pause();
+    resolve();
+  }));
+  await new Promise((resolve) => setTimeout(resolve, 30));
+  const { reads } = readableStats();
+  assert.ok(endless.readableLength > 0);
+  assert.ok(endless.readableLength <= 2 * endless.readableHighWaterMark);
+  await new Promise((resolve) => setTimeout(resolve, 30));
+  assert.strictEqual(readableStats().reads, reads);
+  endless.destroy();
+  await new Promise((resolve) => endless.on('close', resolve));
+  assert.strictEqual(readableStats().closes, 2);
+
+  const failing = readable('failing');
+  let received = '';
+  failing.on('data', (chunk) => {
+    received += chunk;
+  });
+  const err = await new Promise((resolve) => failing.on('error', resolve));
+  assert.strictEqual(err.message, 'disk on fire');
+  assert.strictEqual(received, 'abcabc');
+};
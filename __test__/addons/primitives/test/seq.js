@@ -0,0 +1,40 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ seqCount, seqPairs, seqPanic, seqStopped }) {
+  const start = seqStopped();
+  assert.deepStrictEqual([...seqCount(5)], [0, 1, 2, 3, 4]);
+  assert.strictEqual(seqStopped(), start + 1);
+
+  // Breaking out of a loop stops the sequence.
+  for (const v of seqCount()) {
+    if (v === 3) {
+      break;
+    }
+  }
+  assert.strictEqual(seqStopped(), start + 2);
+  assert.deepStrictEqual(new Map(seqPairs()), new Map([['a', 1], ['b', 2]]));
+
+  const panicking = seqPanic();
+  assert.deepStrictEqual(panicking.next(), { value: 1, done: false });
+  assert.throws(() => panicking.next(), { message: 'napisys: panic in Go function: seq broke' });
+  assert.deepStrictEqual(panicking.next(), { value: undefined, done: true });
+
+  const thrown = seqCount();
+  thrown.next();
+  assert.throws(() => thrown.throw(new TypeError('stop')), TypeError);
+  assert.strictEqual(seqStopped(), start + 3);
+
+  // Iterators collected before the end stop their sequence.
+  if (global.gc) {
+    for (let i = 0; i < 100; i++) {
+      seqCount().next();
+    }
+    for (let i = 0; i < 3 && seqStopped() < start + 103; i++) {
+      global.gc();
+      await new Promise((resolve) => setTimeout(resolve, 10));
+    }
+    assert.strictEqual(seqStopped(), start + 103);
+  }
+};
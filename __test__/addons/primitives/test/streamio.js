@@ -0,0 +1,49 @@
+'use strict'
+
+const assert = require('assert');
+const { Readable, Writable } = require('stream');
+
+// sink collects what is written to it, slowly enough to exercise 'drain'.
+function sink(chunks) {
+  return new Writable({
+    highWaterMark: 16,
+    write(chunk, encoding, callback) {
+      chunks.push(chunk);
+      setImmediate(callback);
+    },
+  });
+}
+
+module.exports = async function ({ streamCopy, streamReadAll, streamWriteClose }) {
+  const input = Array.from({ length: 200 }, (_, i) => `line ${i}\n`);
+  const chunks = [];
+  const readable = Readable.from(input);
+  const writable = sink(chunks);
+  assert.strictEqual(await streamCopy(readable, writable), null);
+  assert.strictEqual(Buffer.concat(chunks).toString(), input.join(''));
+
+  // The listeners of the reader and the writer go away with them.
+  await new Promise((resolve) => setImmediate(resolve));
+  for (const event of ['data', 'end', 'error', 'close']) {
+    assert.strictEqual(readable.listenerCount(event), 0, event);
+  }
+  for (const event of ['error', 'close', 'drain', 'finish']) {
+    assert.strictEqual(writable.listenerCount(event), 0, event);
+  }
+
+  // Chunks other than Buffers and strings fail the read instead of being
+  // skipped.
+  const objects = Readable.from(['text', { not: 'bytes' }, 'more']);
+  const [text, err] = await streamReadAll(objects);
+  assert.strictEqual(text, 'text');
+  assert.match(err, /cannot read a map\[string\]interface \{\} chunk from a stream/);
+  assert.ok(objects.destroyed);
+
+  assert.strictEqual(await streamWriteClose(sink([]), 'done'), null);
+
+  // A stream destroyed before 'finish' is a failure of Close, not a success.
+  const stuck = new Writable({ write() {} });
+  const closed = streamWriteClose(stuck, 'never flushed');
+  setTimeout(() => stuck.destroy(), 20);
+  assert.strictEqual(await closed, 'io: read/write on closed pipe');
+};
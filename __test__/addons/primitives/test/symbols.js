@@ -0,0 +1,17 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function ({ symbolMethods, symbolFor }) {
+  const object = symbolMethods();
+  assert.strictEqual(object.name(), 'name');
+  assert.strictEqual(object[Symbol.toStringTag](), 'toStringTag');
+  assert.strictEqual(object[Symbol.for('primitives.describe')](), 'describe');
+  // Methods stay callable once detached from a collected object.
+  const describe = symbolMethods()[Symbol.for('primitives.describe')];
+  global.gc && global.gc();
+  assert.strictEqual(describe(), 'describe');
+
+  assert.strictEqual(symbolFor('primitives.key'), Symbol.for('primitives.key'));
+  assert.strictEqual(symbolFor(''), Symbol.for(''));
+};
@@ -0,0 +1,43 @@
+'use strict'
+
+const assert = require('assert');
+
+module.exports = async function (addon) {
+  const { wrapCounter, incCounter, newLabel, readLabel, readLabelAsCounter } = addon;
+
+  const counter = {};
+  wrapCounter(counter);
+  assert.strictEqual(incCounter(counter), 42);
+  assert.strictEqual(incCounter(counter), 43);
+  assert.throws(() => wrapCounter(counter), {
+    name: 'TypeError',
+    message: 'The object is already wrapped or type-tagged',
+  });
+
+  const mismatch = {
+    name: 'TypeError',
+    code: 'ERR_INVALID_ARG_TYPE',
+    message: /^The object does not hold a Go \*main\.taggedCounter$/,
+  };
+  for (const bad of [{}, 5, null, undefined, newLabel('x'), () => 1]) {
+    assert.throws(() => incCounter(bad), mismatch);
+  }
+
+  const label = newLabel('hello');
+  assert.strictEqual(typeof label, 'object');
+  assert.strictEqual(readLabel(label), 'hello');
+  assert.throws(() => readLabelAsCounter(label), mismatch);
+  // A wrapped object is not an external, even with the right tag.
+  assert.throws(() => readLabel(counter), { code: 'ERR_INVALID_ARG_TYPE' });
+  assert.throws(() => readLabel({}), { code: 'ERR_INVALID_ARG_TYPE' });
+
+  // The handles are released with their objects.
+  for (let i = 0; i < 10000; i++) {
+    wrapCounter({});
+    newLabel('tmp');
+  }
+  global.gc();
+  await new Promise((resolve) => setImmediate(resolve));
+  global.gc();
+  assert.strictEqual(readLabel(label), 'hello');
+};
@@ -86,6 +86,33 @@ typedef enum {
 // in file js_native_api_v8.cc. Please also update the definition of
 // `napi_status` in doc/api/n-api.md to reflect the newly added value(s).
 
+// Used by napi_get_all_property_names (N-API version 6).
+typedef enum {
+  napi_key_include_prototypes,
+  napi_key_own_only
+} napi_key_collection_mode;
+
+typedef enum {
+  napi_key_all_properties = 0,
+  napi_key_writable = 1,
+  napi_key_enumerable = 1 << 1,
+  napi_key_configurable = 1 << 2,
+  napi_key_skip_strings = 1 << 3,
+  napi_key_skip_symbols = 1 << 4
+} napi_key_filter;
+
+typedef enum {
+  napi_key_keep_numbers,
+  napi_key_numbers_to_strings
+} napi_key_conversion;
+
+// Used by napi_type_tag_object and napi_check_object_type_tag (N-API
+// version 8).
+typedef struct {
+  uint64_t lower;
+  uint64_t upper;
+} napi_type_tag;
+
 typedef napi_value (*napi_callback)(napi_env env,
                                     napi_callback_info info);
 typedef void (*napi_finalize)(napi_env env,
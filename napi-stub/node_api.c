@@ -133,6 +133,15 @@ napi_create_symbol(
         return napi_ok;
 }
 
+NAPI_EXTERN napi_status 
+node_api_symbol_for(
+    napi_env env, 
+    const char* utf8description, 
+    size_t length, 
+    napi_value* result) {
+        return napi_ok;
+}
+
 NAPI_EXTERN napi_status 
 napi_create_function(
     napi_env env, 
@@ -306,6 +315,17 @@ napi_get_property_names(
         return napi_ok;
 }
 
+NAPI_EXTERN napi_status 
+napi_get_all_property_names(
+    napi_env env, 
+    napi_value object, 
+    napi_key_collection_mode key_mode, 
+    napi_key_filter key_filter, 
+    napi_key_conversion key_conversion, 
+    napi_value* result) {
+        return napi_ok;
+}
+
 NAPI_EXTERN napi_status 
 napi_set_property(
     napi_env env,
@@ -1240,3 +1260,41 @@ napi_ref_threadsafe_function(
 }
 
 #endif  // NAPI_VERSION >= 4
+
+NAPI_EXTERN napi_status 
+napi_object_freeze(
+    napi_env env, 
+    napi_value object) {
+        return napi_ok;
+}
+
+NAPI_EXTERN napi_status 
+napi_object_seal(
+    napi_env env, 
+    napi_value object) {
+        return napi_ok;
+}
+
+NAPI_EXTERN napi_status 
+napi_type_tag_object(
+    napi_env env, 
+    napi_value value, 
+    const napi_type_tag* type_tag) {
+        return napi_ok;
+}
+
+NAPI_EXTERN napi_status 
+napi_check_object_type_tag(
+    napi_env env, 
+    napi_value value, 
+    const napi_type_tag* type_tag, 
+    bool* result) {
+        return napi_ok;
+}
+
+NAPI_EXTERN napi_status 
+node_api_get_module_file_name(
+    napi_env env, 
+    const char** result) {
+        return napi_ok;
+}
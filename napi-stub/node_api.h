@@ -232,6 +232,13 @@ napi_ref_threadsafe_function(napi_env env, napi_threadsafe_function func);
 
 #endif  // NAPI_VERSION >= 4
 
+#if NAPI_VERSION >= 9
+
+NAPI_EXTERN napi_status
+node_api_get_module_file_name(napi_env env, const char** result);
+
+#endif  // NAPI_VERSION >= 9
+
 EXTERN_C_END
 
 #endif  // SRC_NODE_API_H_
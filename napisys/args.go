@@ -0,0 +1,286 @@
+package napisys
+
+/*
+#include "gonapi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Callback arguments
+// Args gives typed access to the arguments of a native callback. Calls with up
+// to six arguments are read by value with a single transition to C, so GetArgs
+// does not allocate for them; longer argument lists are copied into a slice.
+// The typed accessors throw a TypeError naming the index of the offending
+// argument and report false, in which case the callback should return nil
+// right away. The accessors ending with Or return a default value when the
+// argument is missing or undefined.
+
+// argsInline is the number of arguments stored inside Args itself.
+const argsInline = C.GO_CB_ARGS_INLINE
+
+// Args holds the arguments, the this value and the data of a callback call.
+// It is only valid until the callback returns.
+type Args struct {
+	env    Env
+	info   CallbackInfo
+	argc   int
+	inline [argsInline]Value
+	rest   []Value
+	this   Value
+	data   unsafe.Pointer
+}
+
+// GetArgs function is used within a callback function to retrieve the
+// arguments, the this value and the data of the call. It replaces GetCbInfo.
+// [in] env: The environment that the API is invoked under.
+// [in] cbinfo: The callback info passed into the callback function.
+// N-API version: 1
+func GetArgs(env Env, cbinfo CallbackInfo) (Args, Status) {
+	var res = C.GetGoCbArgs(env, cbinfo)
+	args := Args{
+		env:  env,
+		info: cbinfo,
+		argc: int(res.argc),
+		this: Value(res.this_arg),
+		data: res.data,
+	}
+	if res.status != C.napi_ok {
+		return args, Status(res.status)
+	}
+	if args.argc <= argsInline {
+		args.inline = res.argv
+		return args, Status(res.status)
+	}
+	args.rest = make([]Value, args.argc)
+	var argc = C.size_t(args.argc)
+	var status = C.napi_get_cb_info(env, cbinfo, &argc, valuesPtr(args.rest), nil, nil)
+	return args, Status(status)
+}
+
+// Len returns the number of arguments actually passed by the caller.
+func (a *Args) Len() int {
+	return a.argc
+}
+
+// At returns the argument at index i, or undefined if the caller passed fewer
+// arguments.
+func (a *Args) At(i int) Value {
+	if v, ok := a.arg(i); ok {
+		return v
+	}
+	res, _ := GetUndefined(a.env)
+	return res
+}
+
+// Values returns a copy of all the arguments.
+func (a *Args) Values() []Value {
+	values := make([]Value, a.argc)
+	if a.rest != nil {
+		copy(values, a.rest)
+	} else {
+		copy(values, a.inline[:a.argc])
+	}
+	return values
+}
+
+// This returns the this value of the call.
+func (a *Args) This() Value {
+	return a.this
+}
+
+// NewTarget returns the new.target of the call, or nil when the callback was
+// not invoked as a constructor.
+func (a *Args) NewTarget() Value {
+	res, _ := GetNewTarget(a.env, a.info)
+	return res
+}
+
+// Data returns the data pointer of the callback.
+func (a *Args) Data() unsafe.Pointer {
+	return a.data
+}
+
+// Has reports whether the argument at index i was passed and is not undefined.
+func (a *Args) Has(i int) bool {
+	v, ok := a.arg(i)
+	if !ok {
+		return false
+	}
+	t, _ := TypeOf(a.env, v)
+	return t != C.napi_undefined
+}
+
+// Int32 returns the argument at index i, which must be a Number, converted to
+// an int32.
+func (a *Args) Int32(i int) (int32, bool) {
+	v, _ := a.arg(i)
+	res, status := GetValueInt32(a.env, v)
+	if status != C.napi_ok {
+		return 0, a.typeError(i, "number")
+	}
+	return res, true
+}
+
+// Int32Or is like Int32 but returns def when the argument is missing or
+// undefined.
+func (a *Args) Int32Or(i int, def int32) (int32, bool) {
+	if !a.Has(i) {
+		return def, true
+	}
+	return a.Int32(i)
+}
+
+// Float64 returns the argument at index i, which must be a Number.
+func (a *Args) Float64(i int) (float64, bool) {
+	v, _ := a.arg(i)
+	res, status := GetValueDouble(a.env, v)
+	if status != C.napi_ok {
+		return 0, a.typeError(i, "number")
+	}
+	return res, true
+}
+
+// Float64Or is like Float64 but returns def when the argument is missing or
+// undefined.
+func (a *Args) Float64Or(i int, def float64) (float64, bool) {
+	if !a.Has(i) {
+		return def, true
+	}
+	return a.Float64(i)
+}
+
+// Bool returns the argument at index i, which must be a Boolean.
+func (a *Args) Bool(i int) (bool, bool) {
+	v, _ := a.arg(i)
+	res, status := GetValueBool(a.env, v)
+	if status != C.napi_ok {
+		return false, a.typeError(i, "boolean")
+	}
+	return res, true
+}
+
+// BoolOr is like Bool but returns def when the argument is missing or
+// undefined.
+func (a *Args) BoolOr(i int, def bool) (bool, bool) {
+	if !a.Has(i) {
+		return def, true
+	}
+	return a.Bool(i)
+}
+
+// String returns the argument at index i, which must be a String.
+func (a *Args) String(i int) (string, bool) {
+	v, _ := a.arg(i)
+	res, err := stringValue(a.env, v)
+	if err != nil {
+		return "", a.typeError(i, "string")
+	}
+	return res, true
+}
+
+// StringOr is like String but returns def when the argument is missing or
+// undefined.
+func (a *Args) StringOr(i int, def string) (string, bool) {
+	if !a.Has(i) {
+		return def, true
+	}
+	return a.String(i)
+}
+
+// Func returns the argument at index i, which must be a Function.
+func (a *Args) Func(i int) (Value, bool) {
+	return a.typed(i, C.napi_function, "function")
+}
+
+// FuncOr is like Func but returns def when the argument is missing or
+// undefined.
+func (a *Args) FuncOr(i int, def Value) (Value, bool) {
+	if !a.Has(i) {
+		return def, true
+	}
+	return a.Func(i)
+}
+
+// Object returns the argument at index i, which must be an Object.
+func (a *Args) Object(i int) (Value, bool) {
+	return a.typed(i, C.napi_object, "object")
+}
+
+// ObjectOr is like Object but returns def when the argument is missing or
+// undefined.
+func (a *Args) ObjectOr(i int, def Value) (Value, bool) {
+	if !a.Has(i) {
+		return def, true
+	}
+	return a.Object(i)
+}
+
+// Throw throws err as a JavaScript Error, see JSError, and returns nil so that
+// callbacks can write `return args.Throw(err)`.
+func (a *Args) Throw(err error) Value {
+	throwError(a.env, err)
+	return nil
+}
+
+func (a *Args) arg(i int) (Value, bool) {
+	if i < 0 || i >= a.argc {
+		return nil, false
+	}
+	if a.rest != nil {
+		return a.rest[i], true
+	}
+	return a.inline[i], true
+}
+
+func (a *Args) typed(i int, want ValueType, name string) (Value, bool) {
+	v, ok := a.arg(i)
+	if !ok {
+		return nil, a.typeError(i, name)
+	}
+	if t, _ := TypeOf(a.env, v); t != want {
+		return nil, a.typeError(i, name)
+	}
+	return v, true
+}
+
+// typeError throws the TypeError reported for the argument at index i and
+// returns false.
+func (a *Args) typeError(i int, want string) bool {
+	received := "undefined"
+	if v, ok := a.arg(i); ok {
+		t, _ := TypeOf(a.env, v)
+		received = typeName(t)
+	}
+	msg := fmt.Sprintf("The argument at index %d must be of type %s. Received type %s", i, want, received)
+	ThrowTypeError(a.env, msg, "ERR_INVALID_ARG_TYPE")
+	return false
+}
+
+// typeName returns the name typeof gives to values of type t.
+func typeName(t ValueType) string {
+	switch t {
+	case C.napi_undefined:
+		return "undefined"
+	case C.napi_null:
+		return "null"
+	case C.napi_boolean:
+		return "boolean"
+	case C.napi_number:
+		return "number"
+	case C.napi_string:
+		return "string"
+	case C.napi_symbol:
+		return "symbol"
+	case C.napi_function:
+		return "function"
+	case C.napi_bigint:
+		return "bigint"
+	case C.napi_external:
+		return "external"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,195 @@
+package napisys
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Async iterators
+// NewAsyncIterator exposes a Go channel to JavaScript as an async iterator, to
+// be consumed with for await. The channel is only read when JavaScript calls
+// next(), so a producer blocked on a full channel is naturally held back by a
+// slow consumer. Values are converted with ToValue on the main thread.
+// Calls to next() that overlap are served in order. Breaking out of a for await
+// loop calls return(), which ends the iteration and cancels the producer: the
+// channel is drained until it is closed, so that a producer blocked on a send
+// is released, and the done channel given to NewAsyncIteratorWithDone is
+// closed, so that a producer selecting on it can stop early.
+
+// iterResult is the object an iterator returns from next().
+type iterResult struct {
+	Value interface{} `json:"value"`
+	Done  bool        `json:"done"`
+}
+
+// doneResult ends an iteration with an undefined value.
+var doneResult = iterResult{Value: Value(nil), Done: true}
+
+// asyncIterator is the Go side of an iterator created by NewAsyncIterator.
+// last is only used on the main thread. finished is set by the first read that
+// sees the end of the iteration, reads being ordered by the last channel.
+type asyncIterator[T any] struct {
+	ch       <-chan T
+	errc     <-chan error
+	done     chan<- struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	last     chan struct{}
+	finished atomic.Bool
+}
+
+// NewAsyncIterator function creates a JavaScript async iterator yielding the
+// values received from ch. Iteration ends when ch is closed, or with a
+// rejection when errc yields a non-nil error. errc may be nil; otherwise the
+// producer must send on it or close it once ch is closed. If the iterator
+// cannot be created, a JavaScript exception is left pending and nil is
+// returned.
+// [in] env: The environment that the API is invoked under.
+// [in] ch: The channel to read the values from.
+// [in] errc: The channel on which the producer reports its failure.
+func NewAsyncIterator[T any](env Env, ch <-chan T, errc <-chan error) Value {
+	return NewAsyncIteratorWithDone(env, ch, errc, nil)
+}
+
+// NewAsyncIteratorWithDone function is like NewAsyncIterator, but also closes
+// done when JavaScript calls return() before the end of the iteration, so that
+// the producer can select on done to stop early.
+// [in] env: The environment that the API is invoked under.
+// [in] ch: The channel to read the values from.
+// [in] errc: The channel on which the producer reports its failure.
+// [in] done: The channel closed when the iteration is cancelled, may be nil.
+func NewAsyncIteratorWithDone[T any](env Env, ch <-chan T, errc <-chan error, done chan<- struct{}) Value {
+	it := &asyncIterator[T]{ch: ch, errc: errc, done: done, stop: make(chan struct{})}
+	res, err := newIteratorObject(env, SymbolAsyncIterator, map[string]Func{
+		"next":   it.next,
+		"return": it.ret,
+	})
+	if err != nil {
+		throwError(env, err)
+		return nil
+	}
+	return res
+}
+
+// next reads the next value of the channel on a goroutine, after the reads of
+// the previous calls completed.
+func (it *asyncIterator[T]) next(env Env, args *Args) (interface{}, error) {
+	value, promise, err := NewPromise(env)
+	if err != nil {
+		return nil, err
+	}
+	if it.finished.Load() {
+		promise.Resolve(doneResult)
+		return value, nil
+	}
+	prev, done := it.last, make(chan struct{})
+	it.last = done
+	go func() {
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		promise.Settle(it.receive())
+	}()
+	return value, nil
+}
+
+// receive waits for the next value, the end of the channel, an error or the
+// end of the iteration.
+// A read following the end of the iteration returns at once.
+func (it *asyncIterator[T]) receive() (interface{}, error) {
+	if it.finished.Load() {
+		return doneResult, nil
+	}
+	errc := it.errc
+	for {
+		select {
+		case v, ok := <-it.ch:
+			if !ok {
+				if errc != nil {
+					select {
+					case err := <-errc:
+						if err != nil {
+							it.finish()
+							return nil, err
+						}
+					case <-it.stop:
+					}
+				}
+				it.finish()
+				return doneResult, nil
+			}
+			return iterResult{Value: v}, nil
+		case err, ok := <-errc:
+			if ok && err != nil {
+				it.finish()
+				return nil, err
+			}
+			errc = nil
+		case <-it.stop:
+			return doneResult, nil
+		}
+	}
+}
+
+// ret implements return(): it ends the iteration and cancels the producer.
+func (it *asyncIterator[T]) ret(env Env, args *Args) (interface{}, error) {
+	value, promise, err := NewPromise(env)
+	if err != nil {
+		return nil, err
+	}
+	if !it.finished.Load() {
+		it.finish()
+		if it.done != nil {
+			close(it.done)
+		}
+		go it.drain()
+	}
+	promise.Resolve(iterResult{Value: args.At(0), Done: true})
+	return value, nil
+}
+
+// drain discards the values of the channel until it is closed, once pending
+// reads are over.
+func (it *asyncIterator[T]) drain() {
+	for range it.ch {
+	}
+}
+
+// finish marks the iteration as over and wakes up pending reads. It may be
+// called from any goroutine.
+func (it *asyncIterator[T]) finish() {
+	it.finished.Store(true)
+	it.stopOnce.Do(func() {
+		close(it.stop)
+	})
+}
+
+// newIteratorObject creates an object with the given methods whose method
+// keyed by the well-known symbol Symbol[symbol] returns the object itself.
+func newIteratorObject(env Env, symbol WellKnownSymbol, methods map[string]Func) (Value, error) {
+	res, status := CreateObject(env)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	for name, fn := range methods {
+		method, err := NewFunction(env, name, fn)
+		if err != nil {
+			return nil, err
+		}
+		if err := statusError(env, SetNamedProperty(env, res, name, method)); err != nil {
+			return nil, err
+		}
+	}
+	key, err := WellKnown(env, symbol)
+	if err != nil {
+		return nil, err
+	}
+	self, err := NewFunction(env, "[Symbol."+string(symbol)+"]", func(env Env, args *Args) (interface{}, error) {
+		return args.This(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, statusError(env, SetProperty(env, res, key, self))
+}
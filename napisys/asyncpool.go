@@ -0,0 +1,324 @@
+package napisys
+
+/*
+#include <stdlib.h>
+#include <node_api.h>
+*/
+import "C"
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// Goroutine pool for asynchronous work
+// By default the execute callback of an AsyncWork runs on the libuv threadpool,
+// which is shared with fs, dns and crypto operations of Node.js and has only
+// four threads unless UV_THREADPOOL_SIZE is raised. Slow Go work can starve
+// those operations. After UseAsyncPool is called, CreateAsyncWork returns works
+// whose execute callback runs on a pool of goroutines owned by the add-on, and
+// whose complete callback is delivered on the main thread through the executor
+// of the environment. The rest of the AsyncWork API is unchanged: works are
+// queued, cancelled and deleted with QueueAsyncWork, CancelAsyncWork and
+// DeleteAsyncWork as usual.
+
+// AsyncPriority is the scheduling priority of a work executed by the goroutine
+// pool. Queued works with a higher priority start before any work with a lower
+// priority; works with the same priority start in queueing order.
+type AsyncPriority int
+
+// Priorities accepted by SetAsyncWorkPriority.
+const (
+	AsyncPriorityLow AsyncPriority = iota - 1
+	AsyncPriorityNormal
+	AsyncPriorityHigh
+)
+
+// AsyncPoolOptions configures the goroutine pool used for asynchronous work.
+type AsyncPoolOptions struct {
+	// Workers is the maximum number of execute callbacks running at the same
+	// time for the whole add-on. Zero means runtime.GOMAXPROCS(0).
+	Workers int
+}
+
+// poolJob is a function waiting for, or running on, a pool goroutine.
+type poolJob struct {
+	priority AsyncPriority
+	run      func()
+	queued   bool
+	started  bool
+}
+
+type asyncPool struct {
+	mu      sync.Mutex
+	enabled bool
+	workers int
+	running int
+	queues  [3][]*poolJob
+	works   map[AsyncWork]*poolWork
+}
+
+var pool = &asyncPool{works: make(map[AsyncWork]*poolWork)}
+
+// UseAsyncPool function makes CreateAsyncWork create works executed by the
+// goroutine pool of the add-on instead of the libuv threadpool. Works created
+// before the call keep running on libuv. It may be called again to change the
+// number of workers; running works are never interrupted.
+// [in] opts: The configuration of the pool.
+func UseAsyncPool(opts AsyncPoolOptions) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.enabled = true
+	pool.workers = opts.Workers
+	pool.schedule()
+}
+
+func (p *asyncPool) isEnabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.enabled
+}
+
+func (p *asyncPool) limit() int {
+	if p.workers > 0 {
+		return p.workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func queueIndex(priority AsyncPriority) int {
+	switch {
+	case priority > AsyncPriorityNormal:
+		return 0
+	case priority < AsyncPriorityNormal:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// submit queues job and starts it as soon as a worker is free.
+func (p *asyncPool) submit(job *poolJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.push(job)
+	p.schedule()
+}
+
+// remove takes job out of the queue, reporting false if it already started.
+func (p *asyncPool) remove(job *poolJob) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.unqueue(job)
+}
+
+func (p *asyncPool) push(job *poolJob) {
+	i := queueIndex(job.priority)
+	p.queues[i] = append(p.queues[i], job)
+	job.queued = true
+}
+
+func (p *asyncPool) unqueue(job *poolJob) bool {
+	if !job.queued {
+		return false
+	}
+	i := queueIndex(job.priority)
+	for k, queued := range p.queues[i] {
+		if queued == job {
+			p.queues[i] = append(p.queues[i][:k], p.queues[i][k+1:]...)
+			break
+		}
+	}
+	job.queued = false
+	return true
+}
+
+// next pops the first job of the highest non-empty priority queue.
+func (p *asyncPool) next() *poolJob {
+	for i := range p.queues {
+		if len(p.queues[i]) > 0 {
+			job := p.queues[i][0]
+			p.queues[i][0] = nil
+			p.queues[i] = p.queues[i][1:]
+			job.queued = false
+			job.started = true
+			return job
+		}
+	}
+	return nil
+}
+
+// schedule starts workers while there are queued jobs and free slots. It must
+// be called with the lock held.
+func (p *asyncPool) schedule() {
+	for p.running < p.limit() {
+		job := p.next()
+		if job == nil {
+			return
+		}
+		p.running++
+		go p.work(job)
+	}
+}
+
+// work runs jobs until the queues are empty or the pool was shrunk.
+func (p *asyncPool) work(job *poolJob) {
+	for job != nil {
+		job.run()
+		p.mu.Lock()
+		job = nil
+		if p.running <= p.limit() {
+			job = p.next()
+		}
+		if job == nil {
+			p.running--
+		}
+		p.mu.Unlock()
+	}
+}
+
+// poolWork is the state of an AsyncWork created while the pool is enabled.
+// The AsyncWork handle given to the caller is a unique native allocation used
+// only as the key of the work.
+type poolWork struct {
+	handle   AsyncWork
+	env      Env
+	ex       *executor
+	execute  *AsyncExecuteCaller
+	complete *AsyncCompleteCaller
+	data     unsafe.Pointer
+	job      *poolJob
+
+	// pending is set while the work holds a reference on the executor,
+	// from queue until its complete callback; removeHook removes the close
+	// hook completing it if the environment shuts down in the meantime. Both
+	// are only used on the main thread.
+	pending    bool
+	removeHook func()
+}
+
+func createPoolWork(env Env, execute *AsyncExecuteCaller, complete *AsyncCompleteCaller, data unsafe.Pointer) (AsyncWork, Status) {
+	ex, err := executorFor(env)
+	if err != nil {
+		if serr, ok := err.(*StatusError); ok {
+			return nil, serr.Status
+		}
+		return nil, Status(C.napi_generic_failure)
+	}
+	w := &poolWork{
+		handle:   AsyncWork(C.malloc(1)),
+		env:      env,
+		ex:       ex,
+		execute:  execute,
+		complete: complete,
+		data:     data,
+	}
+	w.job = &poolJob{priority: AsyncPriorityNormal, run: w.run}
+	pool.mu.Lock()
+	pool.works[w.handle] = w
+	pool.mu.Unlock()
+	return w.handle, Status(C.napi_ok)
+}
+
+func lookupPoolWork(work AsyncWork) *poolWork {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.works[work]
+}
+
+func (w *poolWork) run() {
+	if w.execute != nil {
+		w.execute.Cb(w.env, w.data)
+	}
+	w.finish(Status(C.napi_ok))
+}
+
+// finish delivers the complete callback on the main thread. When the
+// environment is shutting down the task cannot be posted anymore, and the
+// close hook registered by queue completes the work as cancelled instead.
+func (w *poolWork) finish(status Status) {
+	w.ex.post(&executorTask{run: func(env Env) error {
+		w.settle(env, status)
+		return nil
+	}})
+}
+
+// settle releases the reference taken by queue and calls the complete
+// callback, once per call to queue.
+func (w *poolWork) settle(env Env, status Status) {
+	if !w.pending {
+		return
+	}
+	w.pending = false
+	w.removeHook()
+	pool.mu.Lock()
+	w.job.started = false
+	pool.mu.Unlock()
+	w.ex.unref(env)
+	if w.complete != nil {
+		w.complete.Cb(env, status, w.data)
+	}
+}
+
+func (w *poolWork) queue() Status {
+	if w.ex.isClosed() {
+		return Status(C.napi_generic_failure)
+	}
+	pool.mu.Lock()
+	if w.job.queued || w.job.started {
+		pool.mu.Unlock()
+		return Status(C.napi_generic_failure)
+	}
+	pool.mu.Unlock()
+	w.ex.ref(w.env)
+	w.pending = true
+	w.removeHook = w.ex.addCloseHook(func(env Env) {
+		pool.remove(w.job)
+		w.settle(env, Status(C.napi_cancelled))
+	})
+	pool.submit(w.job)
+	return Status(C.napi_ok)
+}
+
+func (w *poolWork) cancel() Status {
+	if !pool.remove(w.job) {
+		return Status(C.napi_generic_failure)
+	}
+	w.finish(Status(C.napi_cancelled))
+	return Status(C.napi_ok)
+}
+
+func (w *poolWork) delete() Status {
+	pool.mu.Lock()
+	if pool.unqueue(w.job) {
+		w.pending = false
+		w.removeHook()
+		w.ex.unref(w.env)
+	}
+	delete(pool.works, w.handle)
+	pool.mu.Unlock()
+	C.free(unsafe.Pointer(w.handle))
+	return Status(C.napi_ok)
+}
+
+// SetAsyncWorkPriority function changes the priority of a work executed by the
+// goroutine pool. If the work is queued it is moved according to the new
+// priority; if it already started the call has no effect on it.
+// [in] work: The handle returned by the call to CreateAsyncWork.
+// [in] priority: The new priority of the work.
+// Returns InvalidArg for works executed by the libuv threadpool.
+func SetAsyncWorkPriority(work AsyncWork, priority AsyncPriority) Status {
+	w := lookupPoolWork(work)
+	if w == nil {
+		return Status(C.napi_invalid_arg)
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.unqueue(w.job) {
+		w.job.priority = priority
+		pool.push(w.job)
+		return Status(C.napi_ok)
+	}
+	w.job.priority = priority
+	return Status(C.napi_ok)
+}
@@ -0,0 +1,91 @@
+package napisys
+
+// Awaiting promises
+// Await and AwaitFunc let Go code wait for JavaScript promises. They attach
+// fulfilment and rejection handlers implemented in Go, convert the outcome
+// with FromValue on the main thread and deliver it to a goroutine, so that Go
+// code can orchestrate asynchronous JavaScript without writing JavaScript glue.
+// Both must be called on the main thread, for example from a callback or from
+// a function run with Invoke. Like the await operator, they accept any value:
+// values that are not thenables are delivered as they are. If the environment
+// shuts down before the promise settles, the outcome is ErrEnvClosed.
+
+// Result is the outcome of an awaited promise. Err is a *JSError when the
+// promise was rejected.
+type Result struct {
+	Value interface{}
+	Err   error
+}
+
+// Await function waits for promise to settle and sends its outcome on the
+// returned channel, which receives exactly one Result.
+// [in] env: The environment that the API is invoked under.
+// [in] promise: The promise to wait for.
+func Await(env Env, promise Value) <-chan Result {
+	ch := make(chan Result, 1)
+	AwaitFunc(env, promise, func(r Result) {
+		ch <- r
+	})
+	return ch
+}
+
+// AwaitFunc function waits for promise to settle and calls fn with its outcome
+// on a new goroutine, exactly once.
+// [in] env: The environment that the API is invoked under.
+// [in] promise: The promise to wait for.
+// [in] fn: The function receiving the outcome.
+func AwaitFunc(env Env, promise Value, fn func(Result)) {
+	settled := false
+	var removeHook func()
+	deliver := func(r Result) {
+		if !settled {
+			settled = true
+			if removeHook != nil {
+				removeHook()
+			}
+			go fn(r)
+		}
+	}
+	ex, err := executorFor(env)
+	if err != nil {
+		deliver(Result{Err: err})
+		return
+	}
+	removeHook = ex.addCloseHook(func(Env) {
+		deliver(Result{Err: ErrEnvClosed})
+	})
+	onFulfilled, err := NewFunction(env, "", func(env Env, args *Args) (interface{}, error) {
+		v, err := FromValue(env, args.At(0))
+		deliver(Result{Value: v, Err: err})
+		return nil, nil
+	})
+	if err != nil {
+		deliver(Result{Err: err})
+		return
+	}
+	onRejected, err := NewFunction(env, "", func(env Env, args *Args) (interface{}, error) {
+		deliver(Result{Err: exceptionError(env, args.At(0))})
+		return nil, nil
+	})
+	if err != nil {
+		deliver(Result{Err: err})
+		return
+	}
+	if err := then(env, promise, onFulfilled, onRejected); err != nil {
+		deliver(Result{Err: err})
+	}
+}
+
+// then calls Promise.resolve(value).then(onFulfilled, onRejected).
+func then(env Env, value Value, onFulfilled Value, onRejected Value) error {
+	ctor, err := globalValue(env, "Promise")
+	if err != nil {
+		return err
+	}
+	promise, err := CallMethod(env, ctor, "resolve", value)
+	if err != nil {
+		return err
+	}
+	_, err = CallMethod(env, promise, "then", onFulfilled, onRejected)
+	return err
+}
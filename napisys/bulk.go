@@ -0,0 +1,124 @@
+package napisys
+
+/*
+#include "gonapi.h"
+*/
+import "C"
+import "unsafe"
+
+// Bulk operations
+// Every N-API call made from Go is a cgo call, which costs far more than the
+// N-API call itself when building large values element by element. The
+// functions below move the loop to the C side, so that building an array or
+// setting a batch of properties costs a single transition from Go to C.
+
+// NewArrayFromFloat64s function creates a JavaScript Array whose elements are
+// the Numbers in values.
+// [in] env: The environment that the API is invoked under.
+// [in] values: The numbers to copy into the array.
+func NewArrayFromFloat64s(env Env, values []float64) (Value, Status) {
+	var res C.napi_value
+	var ptr *C.double
+	if len(values) > 0 {
+		ptr = (*C.double)(unsafe.Pointer(&values[0]))
+	}
+	var status = C.NewArrayFromFloat64s(env, ptr, C.size_t(len(values)), &res)
+	return Value(res), Status(status)
+}
+
+// NewArrayFromInt32s function creates a JavaScript Array whose elements are
+// the Numbers in values.
+// [in] env: The environment that the API is invoked under.
+// [in] values: The numbers to copy into the array.
+func NewArrayFromInt32s(env Env, values []int32) (Value, Status) {
+	var res C.napi_value
+	var ptr *C.int32_t
+	if len(values) > 0 {
+		ptr = (*C.int32_t)(unsafe.Pointer(&values[0]))
+	}
+	var status = C.NewArrayFromInt32s(env, ptr, C.size_t(len(values)), &res)
+	return Value(res), Status(status)
+}
+
+// NewArrayFromStrings function creates a JavaScript Array whose elements are
+// the Strings in values, which must be UTF8-encoded.
+// [in] env: The environment that the API is invoked under.
+// [in] values: The strings to copy into the array.
+func NewArrayFromStrings(env Env, values []string) (Value, Status) {
+	var res C.napi_value
+	data, lengths := packStrings(values)
+	var status = C.NewArrayFromStrings(env, data, lengths, C.size_t(len(values)), &res)
+	return Value(res), Status(status)
+}
+
+// NewArrayFromValues function creates a JavaScript Array whose elements are
+// the given values.
+// [in] env: The environment that the API is invoked under.
+// [in] values: The values to store into the array.
+func NewArrayFromValues(env Env, values []Value) (Value, Status) {
+	var res C.napi_value
+	var status = C.NewArrayFromValues(env, valuesPtr(values), C.size_t(len(values)), &res)
+	return Value(res), Status(status)
+}
+
+// SetNamedProperties function sets the properties keys[i] = values[i] on the
+// Object passed in, as SetNamedProperty would do one at a time.
+// [in] env: The environment that the API is invoked under.
+// [in] object: The object on which to set the properties.
+// [in] keys: The names of the properties, UTF8-encoded.
+// [in] values: The values of the properties.
+// Returns InvalidArg if keys and values do not have the same length.
+func SetNamedProperties(env Env, object Value, keys []string, values []Value) Status {
+	if len(keys) != len(values) {
+		return Status(C.napi_invalid_arg)
+	}
+	data, lengths := packStrings(keys)
+	var status = C.SetNamedProperties(env, object, data, lengths, valuesPtr(values), C.size_t(len(keys)))
+	return Status(status)
+}
+
+// GetElements function returns all the elements of a JavaScript Array, holes
+// included as undefined, with a single transition to C.
+// [in] env: The environment that the API is invoked under.
+// [in] array: The array whose elements to read.
+// Returns ArrayExpected if array is not an Array.
+func GetElements(env Env, array Value) ([]Value, Status) {
+	var data *C.napi_value
+	var length C.uint32_t
+	var status = C.GetElements(env, array, &data, &length)
+	if status != C.napi_ok {
+		return nil, Status(status)
+	}
+	values := make([]Value, int(length))
+	if length > 0 {
+		copy(values, unsafe.Slice((*Value)(unsafe.Pointer(data)), int(length)))
+	}
+	return values, Status(status)
+}
+
+// packStrings concatenates values into a single buffer and returns it along
+// with the length of each string, ready to be passed to C.
+func packStrings(values []string) (*C.char, *C.size_t) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	size := 0
+	for _, v := range values {
+		size += len(v)
+	}
+	data := make([]byte, 0, size+1)
+	lengths := make([]C.size_t, len(values))
+	for i, v := range values {
+		data = append(data, v...)
+		lengths[i] = C.size_t(len(v))
+	}
+	data = append(data, 0)
+	return (*C.char)(unsafe.Pointer(&data[0])), &lengths[0]
+}
+
+func valuesPtr(values []Value) *C.napi_value {
+	if len(values) == 0 {
+		return nil
+	}
+	return (*C.napi_value)(unsafe.Pointer(&values[0]))
+}
@@ -0,0 +1,152 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import "fmt"
+
+// Calling JavaScript functions
+// Call, New and CallMethod are convenience wrappers around CallFunction and
+// NewInstance. Their arguments are Go values converted with ToValue, so Values
+// can be mixed with strings, numbers, slices or structs, and they accept any
+// number of arguments, including none. A JavaScript exception thrown by the
+// callee is cleared and returned as a *JSError.
+
+// Call function calls the JavaScript function fn with the given this value and
+// arguments.
+// [in] env: The environment that the API is invoked under.
+// [in] fn: The JavaScript function to be invoked.
+// [in] this: The this object passed to the called function, or nil for
+// undefined.
+// [in] args: The arguments of the call, converted with ToValue.
+func Call(env Env, fn Value, this Value, args ...interface{}) (Value, error) {
+	if this == nil {
+		undefined, err := undefinedValue(env)
+		if err != nil {
+			return nil, err
+		}
+		this = undefined
+	}
+	argv, err := toValues(env, args)
+	if err != nil {
+		return nil, err
+	}
+	var res C.napi_value
+	var status = Status(C.napi_call_function(env, this, fn, C.size_t(len(argv)), valuesPtr(argv), &res))
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return Value(res), nil
+}
+
+// New function instantiates a new JavaScript object using ctor as constructor.
+// [in] env: The environment that the API is invoked under.
+// [in] ctor: The JavaScript function to be invoked as a constructor.
+// [in] args: The arguments of the constructor, converted with ToValue.
+func New(env Env, ctor Value, args ...interface{}) (Value, error) {
+	argv, err := toValues(env, args)
+	if err != nil {
+		return nil, err
+	}
+	var res C.napi_value
+	var status = Status(C.napi_new_instance(env, ctor, C.size_t(len(argv)), valuesPtr(argv), &res))
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return Value(res), nil
+}
+
+// CallMethod function calls the method name of object, with object as this.
+// [in] env: The environment that the API is invoked under.
+// [in] object: The object whose method to call.
+// [in] name: The name of the method.
+// [in] args: The arguments of the call, converted with ToValue.
+// Returns a TypeError *JSError if the property is not a function.
+func CallMethod(env Env, object Value, name string, args ...interface{}) (Value, error) {
+	fn, status := GetNamedProperty(env, object, name)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	if t, _ := TypeOf(env, fn); t != C.napi_function {
+		return nil, &JSError{Name: "TypeError", Message: name + " is not a function"}
+	}
+	return Call(env, fn, object, args...)
+}
+
+// globalValue returns the value found by following path from the global
+// object, for example globalValue(env, "Symbol", "asyncIterator").
+func globalValue(env Env, path ...string) (Value, error) {
+	v, status := GetGlobal(env)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	for _, name := range path {
+		v, status = GetNamedProperty(env, v, name)
+		if status != C.napi_ok {
+			return nil, pendingError(env, status)
+		}
+	}
+	return v, nil
+}
+
+// builtinModule returns a built-in module of Node.js, such as "events" or
+// "stream", through process.getBuiltinModule (Node.js 20.16 and later).
+// Older versions are served by the createRequire function of the module
+// built-in, called with the file name of the add-on. The module built-in is
+// itself loaded through the require function of the global scope, as in the
+// REPL or in code evaluated by node -e or a Worker, or else through the Module
+// class of the main module of a CommonJS program or Worker. Neither exists
+// when the entry point is an ES module, where older versions fail.
+func builtinModule(env Env, name string) (Value, error) {
+	process, err := globalValue(env, "process")
+	if err != nil {
+		return nil, err
+	}
+	if fn, status := GetNamedProperty(env, process, "getBuiltinModule"); status != C.napi_ok {
+		return nil, pendingError(env, status)
+	} else if t, _ := TypeOf(env, fn); t == C.napi_function {
+		return Call(env, fn, process, name)
+	}
+	module, err := moduleClass(env, process)
+	if err != nil {
+		return nil, err
+	}
+	if module == nil {
+		return nil, fmt.Errorf("napisys: cannot load the built-in module %q: process.getBuiltinModule is missing and the entry point is an ES module", name)
+	}
+	filename, status := GetModuleFileName(env)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	require, err := CallMethod(env, module, "createRequire", filename)
+	if err != nil {
+		return nil, err
+	}
+	return Call(env, require, nil, name)
+}
+
+// moduleClass returns the Module class of Node.js, which the module built-in
+// exports, or nil when no require function or CommonJS main module is
+// reachable from the global scope.
+func moduleClass(env Env, process Value) (Value, error) {
+	require, err := globalValue(env, "require")
+	if err != nil {
+		return nil, err
+	}
+	if t, _ := TypeOf(env, require); t == C.napi_function {
+		return Call(env, require, nil, "module")
+	}
+	main, status := GetNamedProperty(env, process, "mainModule")
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	if t, _ := TypeOf(env, main); t != C.napi_object {
+		return nil, nil
+	}
+	module, status := GetNamedProperty(env, main, "constructor")
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return module, nil
+}
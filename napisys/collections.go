@@ -0,0 +1,215 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"fmt"
+	"reflect"
+)
+
+// Maps and Sets
+// Plain Objects only have string keys and their order of enumeration puts
+// integer-like keys first, so Go maps whose keys are not strings are
+// converted into JavaScript Maps instead, and maps of empty structs, the Go
+// idiom for sets, into Sets. Keys are converted like values, except for int64
+// and uint64 keys which become BigInts so that distinct keys never collide
+// once rounded to a Number. Both collections are built by their global
+// constructors from an Array of entries, and read back by iterating over
+// entries().
+
+// ToMap function converts a Go map into a JavaScript Map, whatever the type of
+// its keys, or into a Set when its values are empty structs. Go maps are not
+// ordered, so neither is the result.
+// [in] env: The environment that the API is invoked under.
+// [in] m: The Go map to convert.
+func ToMap(env Env, m interface{}) (Value, error) {
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map {
+		return nil, fmt.Errorf("napisys: cannot convert %T to a Map", m)
+	}
+	s := &toValueState{}
+	if isSetType(rv.Type()) {
+		return setToValue(env, rv, s)
+	}
+	return mapToJSMap(env, rv, s)
+}
+
+// isSetType reports whether t is a map of empty structs.
+func isSetType(t reflect.Type) bool {
+	elem := t.Elem()
+	return elem.Kind() == reflect.Struct && elem.NumField() == 0
+}
+
+// mapToJSMap converts a Go map into a Map built from an Array of [key, value]
+// entries.
+func mapToJSMap(env Env, rv reflect.Value, s *toValueState) (Value, error) {
+	entries := make([]Value, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := keyToValue(env, iter.Key(), s)
+		if err != nil {
+			return nil, err
+		}
+		v, err := reflectToValue(env, iter.Value(), s)
+		if err != nil {
+			return nil, err
+		}
+		entry, status := NewArrayFromValues(env, []Value{key, v})
+		if err := statusError(env, status); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return newCollection(env, "Map", entries)
+}
+
+// setToValue converts the keys of a Go map into a Set.
+func setToValue(env Env, rv reflect.Value, s *toValueState) (Value, error) {
+	keys := make([]Value, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := keyToValue(env, iter.Key(), s)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return newCollection(env, "Set", keys)
+}
+
+// keyToValue converts the key of a Go map, 64-bit integers becoming BigInts.
+func keyToValue(env Env, rv reflect.Value, s *toValueState) (Value, error) {
+	if rv.Kind() == reflect.Interface && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Int64:
+		res, status := CreateBigintInt64(env, rv.Int())
+		return res, statusError(env, status)
+	case reflect.Uint64:
+		res, status := CreateBigintUInt64(env, rv.Uint())
+		return res, statusError(env, status)
+	}
+	return reflectToValue(env, rv, s)
+}
+
+// newCollection instantiates the global constructor ctor with the Array of
+// items as its only argument.
+func newCollection(env Env, ctor string, items []Value) (Value, error) {
+	constructor, err := globalValue(env, ctor)
+	if err != nil {
+		return nil, err
+	}
+	iterable, status := NewArrayFromValues(env, items)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	res, status := NewInstance(env, constructor, []Value{iterable})
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return res, nil
+}
+
+// collectionCtors holds the Map and Set constructors, looked up once per call
+// to FromValue, when the first object that may be a collection is met.
+type collectionCtors struct {
+	resolved bool
+	mapCtor  Value
+	setCtor  Value
+}
+
+func (c *collectionCtors) resolve(env Env) error {
+	if c.resolved {
+		return nil
+	}
+	var err error
+	if c.mapCtor, err = globalValue(env, "Map"); err != nil {
+		return err
+	}
+	if c.setCtor, err = globalValue(env, "Set"); err != nil {
+		return err
+	}
+	c.resolved = true
+	return nil
+}
+
+// collectionFromValue converts a Map or a Set, reporting false for other
+// objects.
+func collectionFromValue(env Env, value Value, depth int, ctors *collectionCtors) (interface{}, bool, error) {
+	if err := ctors.resolve(env); err != nil {
+		return nil, true, err
+	}
+	isMap, _ := InstanceOf(env, value, ctors.mapCtor)
+	if !isMap {
+		if isSet, _ := InstanceOf(env, value, ctors.setCtor); !isSet {
+			return nil, false, nil
+		}
+	}
+	entries, err := CallMethod(env, value, "entries")
+	if err != nil {
+		return nil, true, err
+	}
+	if isMap {
+		res, err := mapFromEntries(env, entries, depth, ctors)
+		return res, true, err
+	}
+	res, err := setFromEntries(env, entries, ctors)
+	return res, true, err
+}
+
+// mapFromEntries converts the [key, value] entries of a Map.
+func mapFromEntries(env Env, entries Value, depth int, ctors *collectionCtors) (map[interface{}]interface{}, error) {
+	res := make(map[interface{}]interface{})
+	for entry, err := range IterateJS(env, entries) {
+		if err != nil {
+			return nil, err
+		}
+		key, err := entryKey(env, entry, ctors)
+		if err != nil {
+			return nil, err
+		}
+		value, status := GetElement(env, entry, 1)
+		if err := statusError(env, status); err != nil {
+			return nil, err
+		}
+		v, err := fromValue(env, value, depth+1, ctors)
+		if err != nil {
+			return nil, err
+		}
+		res[key] = v
+	}
+	return res, nil
+}
+
+// setFromEntries converts the [value, value] entries of a Set.
+func setFromEntries(env Env, entries Value, ctors *collectionCtors) (map[interface{}]struct{}, error) {
+	res := make(map[interface{}]struct{})
+	for entry, err := range IterateJS(env, entries) {
+		if err != nil {
+			return nil, err
+		}
+		key, err := entryKey(env, entry, ctors)
+		if err != nil {
+			return nil, err
+		}
+		res[key] = struct{}{}
+	}
+	return res, nil
+}
+
+// entryKey converts the key of an entry, which must be a primitive to be used
+// as the key of a Go map.
+func entryKey(env Env, entry Value, ctors *collectionCtors) (interface{}, error) {
+	key, status := GetElement(env, entry, 0)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	switch t, _ := TypeOf(env, key); t {
+	case C.napi_object, C.napi_function, C.napi_symbol, C.napi_external:
+		return nil, fmt.Errorf("napisys: cannot use a JavaScript %s as the key of a Go map", typeName(t))
+	}
+	return fromValue(env, key, 0, ctors)
+}
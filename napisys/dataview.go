@@ -0,0 +1,288 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+// DataViews
+// DataView gives Go code direct access to the memory of a JavaScript
+// DataView, to parse or build binary messages handed over by JavaScript
+// without copying them. Like the methods of DataView.prototype, its accessors
+// read and write at byte offsets in the byte order they are given,
+// binary.BigEndian or binary.LittleEndian, and fail with a RangeError when the
+// value does not fit in the view. Varints use the encoding of
+// encoding/binary.
+// A DataView points into memory owned by JavaScript: it must only be used on
+// the main thread, while the JavaScript DataView is reachable and its
+// ArrayBuffer is neither detached nor transferred, which in practice means
+// during the call it was obtained in.
+
+// DataView is the Go side of a JavaScript DataView.
+type DataView struct {
+	data []byte
+}
+
+// GetDataView function returns the Go accessor of a JavaScript DataView.
+// [in] env: The environment that the API is invoked under.
+// [in] value: The JavaScript DataView.
+// Returns a TypeError *JSError if value is not a DataView.
+func GetDataView(env Env, value Value) (DataView, error) {
+	if ok, _ := IsDataview(env, value); !ok {
+		return DataView{}, &JSError{Name: "TypeError", Code: "ERR_INVALID_ARG_TYPE", Message: "The value must be a DataView"}
+	}
+	_, length, data, _, status := GetDataviewInfo(env, value)
+	if err := statusError(env, status); err != nil {
+		return DataView{}, err
+	}
+	if length == 0 {
+		return DataView{}, nil
+	}
+	return DataView{data: unsafe.Slice((*byte)(data), length)}, nil
+}
+
+// NewDataView function creates a JavaScript DataView over a new ArrayBuffer
+// of length bytes, along with its Go accessor.
+// [in] env: The environment that the API is invoked under.
+// [in] length: The length in bytes of the DataView.
+func NewDataView(env Env, length int) (Value, DataView, error) {
+	buffer, _, status := CreateArrayBuffer(env, uint(length))
+	if err := statusError(env, status); err != nil {
+		return nil, DataView{}, err
+	}
+	res, status := CreateDataview(env, uint(length), 0, buffer)
+	if status != C.napi_ok {
+		return nil, DataView{}, pendingError(env, status)
+	}
+	view, err := GetDataView(env, res)
+	return res, view, err
+}
+
+// Len returns the length in bytes of the view.
+func (v DataView) Len() int {
+	return len(v.data)
+}
+
+// Bytes returns the memory of the view. The slice shares the memory of the
+// view and has the same lifetime.
+func (v DataView) Bytes() []byte {
+	return v.data
+}
+
+// slice returns the n bytes at offset, or a RangeError.
+func (v DataView) slice(offset int, n int) ([]byte, error) {
+	if offset < 0 || n > len(v.data) || offset > len(v.data)-n {
+		return nil, errOutOfBounds
+	}
+	return v.data[offset : offset+n], nil
+}
+
+var errOutOfBounds = &JSError{Name: "RangeError", Code: "ERR_OUT_OF_RANGE", Message: "Offset is outside the bounds of the DataView"}
+
+var errVarintOverflow = &JSError{Name: "RangeError", Code: "ERR_OUT_OF_RANGE", Message: "Varint overflows a 64-bit integer"}
+
+// Uint8 returns the byte at offset.
+func (v DataView) Uint8(offset int) (uint8, error) {
+	b, err := v.slice(offset, 1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// SetUint8 stores x at offset.
+func (v DataView) SetUint8(offset int, x uint8) error {
+	b, err := v.slice(offset, 1)
+	if err != nil {
+		return err
+	}
+	b[0] = x
+	return nil
+}
+
+// Int8 returns the signed byte at offset.
+func (v DataView) Int8(offset int) (int8, error) {
+	x, err := v.Uint8(offset)
+	return int8(x), err
+}
+
+// SetInt8 stores x at offset.
+func (v DataView) SetInt8(offset int, x int8) error {
+	return v.SetUint8(offset, uint8(x))
+}
+
+// Uint16 returns the 16-bit unsigned integer at offset.
+func (v DataView) Uint16(offset int, order binary.ByteOrder) (uint16, error) {
+	b, err := v.slice(offset, 2)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint16(b), nil
+}
+
+// SetUint16 stores x at offset.
+func (v DataView) SetUint16(offset int, x uint16, order binary.ByteOrder) error {
+	b, err := v.slice(offset, 2)
+	if err != nil {
+		return err
+	}
+	order.PutUint16(b, x)
+	return nil
+}
+
+// Int16 returns the 16-bit signed integer at offset.
+func (v DataView) Int16(offset int, order binary.ByteOrder) (int16, error) {
+	x, err := v.Uint16(offset, order)
+	return int16(x), err
+}
+
+// SetInt16 stores x at offset.
+func (v DataView) SetInt16(offset int, x int16, order binary.ByteOrder) error {
+	return v.SetUint16(offset, uint16(x), order)
+}
+
+// Uint32 returns the 32-bit unsigned integer at offset.
+func (v DataView) Uint32(offset int, order binary.ByteOrder) (uint32, error) {
+	b, err := v.slice(offset, 4)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint32(b), nil
+}
+
+// SetUint32 stores x at offset.
+func (v DataView) SetUint32(offset int, x uint32, order binary.ByteOrder) error {
+	b, err := v.slice(offset, 4)
+	if err != nil {
+		return err
+	}
+	order.PutUint32(b, x)
+	return nil
+}
+
+// Int32 returns the 32-bit signed integer at offset.
+func (v DataView) Int32(offset int, order binary.ByteOrder) (int32, error) {
+	x, err := v.Uint32(offset, order)
+	return int32(x), err
+}
+
+// SetInt32 stores x at offset.
+func (v DataView) SetInt32(offset int, x int32, order binary.ByteOrder) error {
+	return v.SetUint32(offset, uint32(x), order)
+}
+
+// BigUint64 returns the 64-bit unsigned integer at offset, which JavaScript
+// reads as a BigInt.
+func (v DataView) BigUint64(offset int, order binary.ByteOrder) (uint64, error) {
+	b, err := v.slice(offset, 8)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint64(b), nil
+}
+
+// SetBigUint64 stores x at offset.
+func (v DataView) SetBigUint64(offset int, x uint64, order binary.ByteOrder) error {
+	b, err := v.slice(offset, 8)
+	if err != nil {
+		return err
+	}
+	order.PutUint64(b, x)
+	return nil
+}
+
+// BigInt64 returns the 64-bit signed integer at offset, which JavaScript reads
+// as a BigInt.
+func (v DataView) BigInt64(offset int, order binary.ByteOrder) (int64, error) {
+	x, err := v.BigUint64(offset, order)
+	return int64(x), err
+}
+
+// SetBigInt64 stores x at offset.
+func (v DataView) SetBigInt64(offset int, x int64, order binary.ByteOrder) error {
+	return v.SetBigUint64(offset, uint64(x), order)
+}
+
+// Float32 returns the 32-bit float at offset.
+func (v DataView) Float32(offset int, order binary.ByteOrder) (float32, error) {
+	x, err := v.Uint32(offset, order)
+	return math.Float32frombits(x), err
+}
+
+// SetFloat32 stores x at offset.
+func (v DataView) SetFloat32(offset int, x float32, order binary.ByteOrder) error {
+	return v.SetUint32(offset, math.Float32bits(x), order)
+}
+
+// Float64 returns the 64-bit float at offset.
+func (v DataView) Float64(offset int, order binary.ByteOrder) (float64, error) {
+	x, err := v.BigUint64(offset, order)
+	return math.Float64frombits(x), err
+}
+
+// SetFloat64 stores x at offset.
+func (v DataView) SetFloat64(offset int, x float64, order binary.ByteOrder) error {
+	return v.SetBigUint64(offset, math.Float64bits(x), order)
+}
+
+// Uvarint decodes the unsigned varint at offset and returns it along with the
+// number of bytes it takes.
+func (v DataView) Uvarint(offset int) (uint64, int, error) {
+	if offset < 0 || offset > len(v.data) {
+		return 0, 0, errOutOfBounds
+	}
+	x, n := binary.Uvarint(v.data[offset:])
+	switch {
+	case n == 0:
+		return 0, 0, errOutOfBounds
+	case n < 0:
+		return 0, 0, errVarintOverflow
+	}
+	return x, n, nil
+}
+
+// SetUvarint encodes x as an unsigned varint at offset and returns the number
+// of bytes written. Nothing is written when the varint does not fit.
+func (v DataView) SetUvarint(offset int, x uint64) (int, error) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	b, err := v.slice(offset, n)
+	if err != nil {
+		return 0, err
+	}
+	return copy(b, buf[:n]), nil
+}
+
+// Varint decodes the zig-zag encoded signed varint at offset and returns it
+// along with the number of bytes it takes.
+func (v DataView) Varint(offset int) (int64, int, error) {
+	if offset < 0 || offset > len(v.data) {
+		return 0, 0, errOutOfBounds
+	}
+	x, n := binary.Varint(v.data[offset:])
+	switch {
+	case n == 0:
+		return 0, 0, errOutOfBounds
+	case n < 0:
+		return 0, 0, errVarintOverflow
+	}
+	return x, n, nil
+}
+
+// SetVarint encodes x as a zig-zag encoded signed varint at offset and returns
+// the number of bytes written. Nothing is written when the varint does not
+// fit.
+func (v DataView) SetVarint(offset int, x int64) (int, error) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], x)
+	b, err := v.slice(offset, n)
+	if err != nil {
+		return 0, err
+	}
+	return copy(b, buf[:n]), nil
+}
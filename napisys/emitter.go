@@ -0,0 +1,298 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"runtime"
+	"sync"
+)
+
+// Event emitters
+// An Emitter is a Node.js EventEmitter fed by Go code. JavaScript uses the
+// usual on, once and off methods on the emitter object, while Go code calls
+// Emit from any goroutine. Emissions are carried to the main thread by a
+// thread-safe function owned by the emitter and delivered in order, with their
+// arguments converted by ToValue. An exception thrown by a listener is emitted
+// as an 'error' event, and reported as an uncaught exception when there is no
+// listener for it.
+// The thread-safe function only keeps the event loop alive while listeners are
+// attached, so an emitter nobody listens to does not prevent Node.js from
+// exiting. Listeners are counted by the methods adding and removing them, which
+// the emitter overrides in a prototype of its own.
+
+// Emitter is the Go side of an EventEmitter created by NewEmitter.
+type Emitter struct {
+	state *emitterState
+}
+
+// emitterState is kept apart from Emitter so that the finalizer of the latter
+// can close it while the thread-safe function still references the state.
+type emitterState struct {
+	tsfn   *goThreadsafeFunction
+	object Ref
+
+	mu        sync.Mutex
+	closed    bool
+	shutdown  bool
+	finalized bool
+}
+
+// emission is an event queued by Emit.
+type emission struct {
+	name string
+	args []interface{}
+}
+
+// NewEmitter function creates a JavaScript EventEmitter along with the Emitter
+// used to emit its events from Go. It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+func NewEmitter(env Env) (Value, *Emitter, error) {
+	events, err := builtinModule(env, "events")
+	if err != nil {
+		return nil, nil, err
+	}
+	object, err := New(env, events)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &emitterState{}
+	s.tsfn, err = newGoThreadsafeFunction(env, "napisys:emitter", s.dispatch, s.finalize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := statusError(env, UnrefThreadsafeFunction(env, s.tsfn.fn)); err != nil {
+		s.release()
+		return nil, nil, err
+	}
+	if err := s.watchListeners(env, events, object); err != nil {
+		s.release()
+		return nil, nil, err
+	}
+	ref, status := CreateReference(env, object, 1)
+	if err := statusError(env, status); err != nil {
+		s.release()
+		return nil, nil, err
+	}
+	s.object = ref
+	e := &Emitter{state: s}
+	runtime.SetFinalizer(e, func(e *Emitter) {
+		e.state.release()
+	})
+	return object, e, nil
+}
+
+// Emit queues the event name with the given arguments, converted with ToValue
+// on the main thread. It may be called from any goroutine.
+// Returns ErrClosed once the emitter was closed and ErrEnvClosed once the
+// environment shut down.
+func (e *Emitter) Emit(name string, args ...interface{}) error {
+	s := e.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shutdown {
+		return ErrEnvClosed
+	}
+	if s.closed {
+		return ErrClosed
+	}
+	return s.tsfn.call(&emission{name: name, args: args})
+}
+
+// Close stops the emitter: events already queued are still delivered, later
+// calls to Emit fail. It may be called from any goroutine.
+func (e *Emitter) Close() {
+	e.state.release()
+}
+
+// listenerMethods are the methods of EventEmitter that add or remove
+// listeners. Listeners added with once remove themselves with removeListener.
+var listenerMethods = []string{
+	"addListener", "on", "prependListener", "once", "prependOnceListener",
+	"removeListener", "off", "removeAllListeners",
+}
+
+// watchListeners refs the thread-safe function while the emitter has
+// listeners. The emitter gets a prototype of its own, deriving from
+// EventEmitter.prototype, whose methods adding or removing listeners count
+// them once they are done, so that tracking them adds no listener that
+// listenerCount or eventNames would report.
+func (s *emitterState) watchListeners(env Env, events Value, object Value) error {
+	objectClass, err := globalValue(env, "Object")
+	if err != nil {
+		return err
+	}
+	base, status := GetNamedProperty(env, events, "prototype")
+	if status != C.napi_ok {
+		return pendingError(env, status)
+	}
+	proto, err := CallMethod(env, objectClass, "create", base)
+	if err != nil {
+		return err
+	}
+	for _, name := range listenerMethods {
+		name := name
+		method, err := NewFunction(env, name, func(env Env, args *Args) (interface{}, error) {
+			res, err := callBaseMethod(env, name, args)
+			if err != nil {
+				return nil, err
+			}
+			s.countListeners(env)
+			return res, nil
+		})
+		if err != nil {
+			return err
+		}
+		descriptor := map[string]interface{}{"value": method, "writable": true, "configurable": true}
+		if _, err := CallMethod(env, objectClass, "defineProperty", proto, name, descriptor); err != nil {
+			return err
+		}
+	}
+	_, err = CallMethod(env, objectClass, "setPrototypeOf", object, proto)
+	return err
+}
+
+// callBaseMethod calls the method name of EventEmitter.prototype with the this
+// value and arguments of the call.
+func callBaseMethod(env Env, name string, args *Args) (Value, error) {
+	base, err := builtinModule(env, "events")
+	if err != nil {
+		return nil, err
+	}
+	proto, status := GetNamedProperty(env, base, "prototype")
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	fn, status := GetNamedProperty(env, proto, name)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	values := args.Values()
+	argv := make([]interface{}, len(values))
+	for i, v := range values {
+		argv[i] = v
+	}
+	return Call(env, fn, args.This(), argv...)
+}
+
+// countListeners refs the thread-safe function if the emitter has listeners
+// and unrefs it otherwise.
+func (s *emitterState) countListeners(env Env) {
+	if s.isFinalized() {
+		return
+	}
+	object, status := GetReferenceValue(env, s.object)
+	if status != C.napi_ok {
+		return
+	}
+	names, err := CallMethod(env, object, "eventNames")
+	if err != nil {
+		return
+	}
+	keys, status := GetElements(env, names)
+	if status != C.napi_ok {
+		return
+	}
+	total := int32(0)
+	for _, key := range keys {
+		count, err := CallMethod(env, object, "listenerCount", key)
+		if err != nil {
+			return
+		}
+		n, _ := GetValueInt32(env, count)
+		total += n
+	}
+	s.ref(env, total > 0)
+}
+
+func (s *emitterState) isFinalized() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.finalized
+}
+
+// ref refs or unrefs the thread-safe function unless it was destroyed. A
+// closed emitter still delivers the events queued before, which listeners
+// wait for.
+func (s *emitterState) ref(env Env, ref bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finalized {
+		return
+	}
+	if ref {
+		RefThreadsafeFunction(env, s.tsfn.fn)
+	} else {
+		UnrefThreadsafeFunction(env, s.tsfn.fn)
+	}
+}
+
+// dispatch delivers an emission on the main thread.
+func (s *emitterState) dispatch(env Env, data interface{}) {
+	if env == nil {
+		return
+	}
+	ev := data.(*emission)
+	scope, status := OnpenHandleScope(env)
+	if status != C.napi_ok {
+		return
+	}
+	defer CloseHandleScope(env, scope)
+	object, status := GetReferenceValue(env, s.object)
+	if status != C.napi_ok {
+		return
+	}
+	args, err := toValues(env, append([]interface{}{ev.name}, ev.args...))
+	if err != nil {
+		fatalGoError(env, err)
+		return
+	}
+	exception, ok := emit(env, object, args)
+	if ok {
+		return
+	}
+	if ev.name != "error" {
+		name, _ := stringToValue(env, "error")
+		if exception, ok = emit(env, object, []Value{name, exception}); ok {
+			return
+		}
+	}
+	FatalException(env, exception)
+}
+
+// emit calls object.emit(...args) and returns the exception it threw, if any.
+func emit(env Env, object Value, args []Value) (Value, bool) {
+	if fn, status := GetNamedProperty(env, object, "emit"); status == C.napi_ok {
+		CallFunction(env, object, fn, args)
+	}
+	if pending, _ := IsExceptionPending(env); pending {
+		exception, _ := GetAndClearLastException(env)
+		return exception, false
+	}
+	return nil, true
+}
+
+// release releases the thread-safe function once.
+func (s *emitterState) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	ReleaseThreadsafeFunction(s.tsfn.fn, C.napi_tsfn_release)
+}
+
+// finalize runs once the thread-safe function is destroyed, after release or
+// when the environment shuts down.
+func (s *emitterState) finalize(env Env) {
+	s.mu.Lock()
+	s.shutdown = !s.closed
+	s.closed = true
+	s.finalized = true
+	s.mu.Unlock()
+	if s.object != nil {
+		DeleteReference(env, s.object)
+	}
+}
@@ -0,0 +1,182 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEnvClosed is returned when work is scheduled on an environment that has
+// already been shut down, for example after the worker thread or the main
+// Node.js instance that owned it exited.
+var ErrEnvClosed = errors.New("napisys: environment has been shut down")
+
+// ErrNoExecutor is returned by Post and Invoke when no executor runs for the
+// environment: either none was started, or the environment has shut down and
+// its executor was dropped. Call StartExecutor from the main thread, for
+// example while initializing the add-on, before scheduling work from
+// goroutines.
+var ErrNoExecutor = errors.New("napisys: no executor started for environment")
+
+// ErrClosed is returned when an object of this package, such as an Emitter, is
+// used after being closed.
+var ErrClosed = errors.New("napisys: use of closed object")
+
+// StatusError is the error returned by the higher level helpers when an
+// underlying N-API call does not return napi_ok.
+type StatusError struct {
+	Status  Status
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("napisys: N-API call failed with status %d", int(e.Status))
+	}
+	return fmt.Sprintf("napisys: %s (status %d)", e.Message, int(e.Status))
+}
+
+// statusError returns nil when status is napi_ok, otherwise a StatusError
+// filled with the message reported by GetLastErrorInfo.
+func statusError(env Env, status Status) error {
+	if status == C.napi_ok {
+		return nil
+	}
+	err := &StatusError{Status: status}
+	if info, s := GetLastErrorInfo(env); s == C.napi_ok && info != nil && info.error_message != nil {
+		err.Message = C.GoString(info.error_message)
+	}
+	return err
+}
+
+// JSError is a JavaScript exception converted to a Go error. Name, Message,
+// Code and Stack are read from the thrown object when it is an Error; when a
+// primitive was thrown, Message holds its string conversion.
+// A JSError given to ToValue, or returned to JavaScript by the helpers of this
+// package, is thrown as an Error of the same name carrying the same code.
+type JSError struct {
+	Name    string
+	Message string
+	Code    string
+	Stack   string
+}
+
+func (e *JSError) Error() string {
+	name := e.Name
+	if name == "" {
+		name = "Error"
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("%s [%s]: %s", name, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", name, e.Message)
+}
+
+// exceptionError converts a JavaScript exception, already cleared from the
+// environment, into a *JSError.
+func exceptionError(env Env, exception Value) error {
+	err := &JSError{}
+	if isError, _ := IsError(env, exception); !isError {
+		str, status := CoerceToString(env, exception)
+		if status == C.napi_ok {
+			err.Message, _ = stringValue(env, str)
+		}
+		return err
+	}
+	err.Name = stringProperty(env, exception, "name")
+	err.Message = stringProperty(env, exception, "message")
+	err.Code = stringProperty(env, exception, "code")
+	err.Stack = stringProperty(env, exception, "stack")
+	return err
+}
+
+// pendingError returns the pending JavaScript exception as a *JSError after
+// clearing it, or the error of status when no exception is pending.
+func pendingError(env Env, status Status) error {
+	if pending, _ := IsExceptionPending(env); pending {
+		exception, _ := GetAndClearLastException(env)
+		return exceptionError(env, exception)
+	}
+	return statusError(env, status)
+}
+
+// errorValue creates the JavaScript Error corresponding to err. A *JSError
+// keeps its name and code, an *AggregateError becomes an AggregateError and any
+// other error becomes a plain Error.
+func errorValue(env Env, err error) (Value, error) {
+	var aggregate *AggregateError
+	if errors.As(err, &aggregate) {
+		return aggregateErrorValue(env, aggregate)
+	}
+	name, code, message := "Error", "", err.Error()
+	var jsErr *JSError
+	if errors.As(err, &jsErr) {
+		if jsErr.Name != "" {
+			name = jsErr.Name
+		}
+		code, message = jsErr.Code, jsErr.Message
+	}
+	msg, status := CreateStringUtf8(env, message)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	var res Value
+	switch name {
+	case "TypeError":
+		res, status = CreateTypeError(env, nil, msg)
+	case "RangeError":
+		res, status = CreateRangeError(env, nil, msg)
+	default:
+		res, status = CreateError(env, msg, nil)
+	}
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	if name != "Error" && name != "TypeError" && name != "RangeError" {
+		if err := setString(env, res, "name", name); err != nil {
+			return nil, err
+		}
+	}
+	if code != "" {
+		if err := setString(env, res, "code", code); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// throwError throws the JavaScript Error corresponding to err, see
+// errorValue.
+func throwError(env Env, err error) {
+	exception, cerr := errorValue(env, err)
+	if cerr != nil {
+		ThrowError(env, err.Error(), "")
+		return
+	}
+	Throw(env, exception)
+}
+
+// throwStatus makes sure that a failed call leaves an exception pending,
+// throwing the error of status when the call did not throw one itself.
+func throwStatus(env Env, status Status) {
+	if pending, _ := IsExceptionPending(env); !pending {
+		throwError(env, statusError(env, status))
+	}
+}
+
+// aggregateErrorValue creates a JavaScript AggregateError holding the errors
+// of aggregate.
+func aggregateErrorValue(env Env, aggregate *AggregateError) (Value, error) {
+	ctor, err := globalValue(env, "AggregateError")
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]interface{}, len(aggregate.Errors))
+	for i, e := range aggregate.Errors {
+		errs[i] = e
+	}
+	return New(env, ctor, errs, "All tasks failed")
+}
@@ -0,0 +1,55 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+
+// Evaluating scripts and requiring modules
+// Eval runs JavaScript source in the global scope, as indirect eval does.
+// Require loads modules the way a require call in the addon itself would:
+// through the require function Node.js creates for the file of the addon, so
+// built-in modules such as path, util or worker_threads, packages installed
+// next to the addon and paths relative to it all resolve without JavaScript
+// glue files.
+
+// Eval function runs source as a script in the global scope and returns the
+// value of its last statement.
+// [in] env: The environment that the API is invoked under.
+// [in] source: The JavaScript source to run.
+// Returns the exception thrown by the script, including a SyntaxError, as a
+// *JSError.
+func Eval(env Env, source string) (Value, error) {
+	script, err := stringToValue(env, source)
+	if err != nil {
+		return nil, err
+	}
+	res, status := RunScript(env, script)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return res, nil
+}
+
+// Require function loads a module as require(id) would from the file of the
+// addon.
+// [in] env: The environment that the API is invoked under.
+// [in] id: The module to load, for example "node:path", a package name or a
+// path relative to the addon.
+// Returns the exception thrown while resolving or loading the module, such as
+// an Error with the code MODULE_NOT_FOUND, as a *JSError.
+func Require(env Env, id string) (Value, error) {
+	filename, status := GetModuleFileName(env)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	module, err := builtinModule(env, "module")
+	if err != nil {
+		return nil, err
+	}
+	require, err := CallMethod(env, module, "createRequire", filename)
+	if err != nil {
+		return nil, err
+	}
+	return Call(env, require, nil, id)
+}
@@ -0,0 +1,414 @@
+package napisys
+
+/*
+#include <stdlib.h>
+#include "gonapi.h"
+*/
+import "C"
+import (
+	"context"
+	"fmt"
+	"runtime/cgo"
+	"sync"
+)
+
+// Main-thread executor
+// N-API calls are only legal on the thread that owns the Env, while Go code
+// usually produces its results on goroutines. The executor is a single
+// thread-safe function created per Env which carries Go closures from any
+// goroutine to the main thread and runs them there, each one inside its own
+// handle scope.
+// The executor does not keep the event loop alive by itself. Code that expects
+// work to be posted later should call KeepAlive so that Node.js does not exit
+// in the meantime.
+
+// goThreadsafeFunction is a thread-safe function whose call_js callback and
+// finalizer are implemented in Go. The Go side is reached through a cgo.Handle
+// stored as the context of the thread-safe function, and every queued item is
+// a cgo.Handle to an arbitrary Go value.
+type goThreadsafeFunction struct {
+	fn       ThreadsafeFunction
+	handle   cgo.Handle
+	dispatch func(env Env, data interface{})
+	finalize func(env Env)
+}
+
+// newGoThreadsafeFunction creates a thread-safe function without a JavaScript
+// callback. dispatch is called on the main thread for every item queued with
+// call, with a nil env when the function is being torn down with items still
+// in the queue. finalize is called once when the function is destroyed.
+// It must be called on the main thread.
+func newGoThreadsafeFunction(env Env, name string, dispatch func(Env, interface{}), finalize func(Env)) (*goThreadsafeFunction, error) {
+	cname, status := CreateStringUtf8(env, name)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	t := &goThreadsafeFunction{dispatch: dispatch, finalize: finalize}
+	t.handle = cgo.NewHandle(t)
+	var res C.napi_threadsafe_function
+	status = Status(C.CreateGoThreadsafeFunction(env, cname, C.uintptr_t(t.handle), &res))
+	if err := statusError(env, status); err != nil {
+		t.handle.Delete()
+		return nil, err
+	}
+	t.fn = ThreadsafeFunction(res)
+	return t, nil
+}
+
+// call queues data for the dispatch function without blocking. It may be
+// called from any goroutine.
+func (t *goThreadsafeFunction) call(data interface{}) error {
+	h := cgo.NewHandle(data)
+	status := Status(C.CallGoThreadsafeFunction(t.fn, C.uintptr_t(h), C.napi_tsfn_nonblocking))
+	switch status {
+	case C.napi_ok:
+		return nil
+	case C.napi_closing:
+		h.Delete()
+		return ErrEnvClosed
+	default:
+		h.Delete()
+		return &StatusError{Status: status}
+	}
+}
+
+//export DispatchGoThreadsafeFunction
+func DispatchGoThreadsafeFunction(env C.napi_env, ctx C.uintptr_t, data C.uintptr_t) {
+	t := cgo.Handle(ctx).Value().(*goThreadsafeFunction)
+	h := cgo.Handle(data)
+	payload := h.Value()
+	h.Delete()
+	t.dispatch(Env(env), payload)
+}
+
+//export FinalizeGoThreadsafeFunction
+func FinalizeGoThreadsafeFunction(env C.napi_env, ctx C.uintptr_t) {
+	h := cgo.Handle(ctx)
+	t := h.Value().(*goThreadsafeFunction)
+	h.Delete()
+	if t.finalize != nil {
+		t.finalize(Env(env))
+	}
+}
+
+// executor owns the thread-safe function used to run Go closures on the main
+// thread of one Env.
+type executor struct {
+	tsfn   *goThreadsafeFunction
+	thread uintptr
+
+	mu      sync.Mutex
+	closed  bool
+	refs    int
+	hookID  int
+	onClose map[int]func(Env)
+}
+
+// executorTask is a unit of work queued on an executor. done, when set,
+// receives the outcome of run, including JavaScript exceptions left pending
+// by it. Without done, failures are reported as uncaught exceptions.
+type executorTask struct {
+	ctx  context.Context
+	run  func(Env) error
+	done func(error)
+}
+
+var executors = struct {
+	sync.Mutex
+	m map[Env]*executor
+}{m: make(map[Env]*executor)}
+
+// StartExecutor function creates the main-thread executor for the given
+// environment. It must be called on the main thread, typically while the add-on
+// is being initialized, before Post or Invoke are used from goroutines.
+// Calling it again for the same environment has no effect.
+// [in] env: The environment that the API is invoked under.
+func StartExecutor(env Env) error {
+	_, err := executorFor(env)
+	return err
+}
+
+// executorFor returns the executor of env, creating it if needed. It must be
+// called on the main thread.
+func executorFor(env Env) (*executor, error) {
+	executors.Lock()
+	defer executors.Unlock()
+	if ex, ok := executors.m[env]; ok && !ex.isClosed() {
+		return ex, nil
+	}
+	ex := &executor{
+		thread:  uintptr(C.CurrentThread()),
+		onClose: make(map[int]func(Env)),
+	}
+	tsfn, err := newGoThreadsafeFunction(env, "napisys:executor", ex.dispatch, ex.finalize)
+	if err != nil {
+		return nil, err
+	}
+	ex.tsfn = tsfn
+	if err := statusError(env, UnrefThreadsafeFunction(env, tsfn.fn)); err != nil {
+		return nil, err
+	}
+	executors.m[env] = ex
+	return ex, nil
+}
+
+// lookupExecutor returns the executor of env without creating it. It may be
+// called from any goroutine.
+func lookupExecutor(env Env) (*executor, error) {
+	executors.Lock()
+	ex, ok := executors.m[env]
+	executors.Unlock()
+	if !ok {
+		return nil, ErrNoExecutor
+	}
+	if ex.isClosed() {
+		return nil, ErrEnvClosed
+	}
+	return ex, nil
+}
+
+func (ex *executor) isClosed() bool {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return ex.closed
+}
+
+// onMainThread reports whether the calling goroutine is running on the thread
+// that owns the environment. Goroutines entered from a native callback stay on
+// that thread until the callback returns, and no other goroutine is ever
+// scheduled on it, so the answer cannot change while the caller uses it.
+func (ex *executor) onMainThread() bool {
+	return uintptr(C.CurrentThread()) == ex.thread
+}
+
+// post queues a task. The lock is held while calling into the thread-safe
+// function so that it cannot be finalized in the meantime.
+func (ex *executor) post(task *executorTask) error {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if ex.closed {
+		return ErrEnvClosed
+	}
+	return ex.tsfn.call(task)
+}
+
+func (ex *executor) dispatch(env Env, data interface{}) {
+	task := data.(*executorTask)
+	if env == nil {
+		if task.done != nil {
+			task.done(ErrEnvClosed)
+		}
+		return
+	}
+	ex.run(env, task)
+}
+
+// run executes a task on the main thread inside a new handle scope.
+func (ex *executor) run(env Env, task *executorTask) {
+	if task.ctx != nil && task.ctx.Err() != nil {
+		if task.done != nil {
+			task.done(task.ctx.Err())
+		}
+		return
+	}
+	scope, status := OnpenHandleScope(env)
+	if err := statusError(env, status); err != nil {
+		if task.done != nil {
+			task.done(err)
+		}
+		return
+	}
+	defer CloseHandleScope(env, scope)
+	ex.exec(env, task)
+}
+
+// exec executes a task on the main thread in the current handle scope.
+func (ex *executor) exec(env Env, task *executorTask) {
+	err := runRecovered(env, task.run)
+	if pending, _ := IsExceptionPending(env); pending {
+		exception, _ := GetAndClearLastException(env)
+		if task.done == nil {
+			FatalException(env, exception)
+			return
+		}
+		if err == nil {
+			err = exceptionError(env, exception)
+		}
+	}
+	if task.done != nil {
+		task.done(err)
+	} else if err != nil {
+		fatalGoError(env, err)
+	}
+}
+
+// runRecovered calls fn and turns a panic into an error, since a panic must
+// never unwind through the native frames of the JavaScript engine.
+func runRecovered(env Env, fn func(Env) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("napisys: panic in main-thread task: %v", r)
+		}
+	}()
+	return fn(env)
+}
+
+// fatalGoError reports err as an 'uncaughtException' in JavaScript.
+func fatalGoError(env Env, err error) {
+	msg, status := CreateStringUtf8(env, err.Error())
+	if status != C.napi_ok {
+		return
+	}
+	exception, status := CreateError(env, msg, nil)
+	if status != C.napi_ok {
+		return
+	}
+	FatalException(env, exception)
+}
+
+func (ex *executor) finalize(env Env) {
+	executors.Lock()
+	if executors.m[env] == ex {
+		delete(executors.m, env)
+	}
+	executors.Unlock()
+	ex.mu.Lock()
+	ex.closed = true
+	hooks := ex.onClose
+	ex.onClose = nil
+	ex.mu.Unlock()
+	for _, hook := range hooks {
+		hook(env)
+	}
+}
+
+// addCloseHook registers fn to be called on the main thread when the
+// environment shuts down. The returned function removes the hook. Once the
+// environment has shut down fn is never called and the returned function does
+// nothing.
+func (ex *executor) addCloseHook(fn func(Env)) func() {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if ex.closed {
+		return func() {}
+	}
+	ex.hookID++
+	id := ex.hookID
+	ex.onClose[id] = fn
+	return func() {
+		ex.mu.Lock()
+		defer ex.mu.Unlock()
+		delete(ex.onClose, id)
+	}
+}
+
+// ref and unref keep the event loop alive while at least one holder exists.
+// They must be called on the main thread.
+func (ex *executor) ref(env Env) {
+	if ex.refs == 0 && !ex.isClosed() {
+		RefThreadsafeFunction(env, ex.tsfn.fn)
+	}
+	ex.refs++
+}
+
+func (ex *executor) unref(env Env) {
+	ex.refs--
+	if ex.refs == 0 && !ex.isClosed() {
+		UnrefThreadsafeFunction(env, ex.tsfn.fn)
+	}
+}
+
+// KeepAlive function prevents the event loop of the environment from exiting
+// until the returned release function is called. It must be called on the main
+// thread, while release may be called from any goroutine, at most once.
+// [in] env: The environment that the API is invoked under.
+func KeepAlive(env Env) (func(), error) {
+	ex, err := executorFor(env)
+	if err != nil {
+		return nil, err
+	}
+	ex.ref(env)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if ex.onMainThread() {
+				ex.unref(env)
+				return
+			}
+			ex.post(&executorTask{run: func(env Env) error {
+				ex.unref(env)
+				return nil
+			}})
+		})
+	}, nil
+}
+
+// Post function schedules fn to run on the main thread of the environment and
+// returns immediately. It may be called from any goroutine. fn runs inside its
+// own handle scope; a JavaScript exception left pending by fn is reported as an
+// 'uncaughtException'.
+// [in] env: The environment that the API is invoked under.
+// [in] fn: The function to run on the main thread.
+// Returns ErrNoExecutor if no executor runs for env, because StartExecutor was
+// never called for it or because the environment has shut down and its
+// executor was dropped, and ErrEnvClosed while the environment shuts down.
+func Post(env Env, fn func(Env)) error {
+	ex, err := lookupExecutor(env)
+	if err != nil {
+		return err
+	}
+	return ex.post(&executorTask{run: func(env Env) error {
+		fn(env)
+		return nil
+	}})
+}
+
+// Invoke function runs fn on the main thread of the environment and waits for
+// its result. It may be called from any goroutine; when called on the main
+// thread itself fn runs immediately, in the handle scope of the caller. Called
+// from any other goroutine, fn runs in its own handle scope that is closed
+// before Invoke returns, so T must not hold a Value: pass a Ref instead.
+// A JavaScript exception left pending by fn is cleared and returned as a
+// *JSError.
+// If ctx is done before fn started, fn is skipped; if ctx is done while fn is
+// queued or running, Invoke returns ctx.Err() without waiting for it.
+// [in] ctx: Context bounding the wait for the result.
+// [in] env: The environment that the API is invoked under.
+// [in] fn: The function to run on the main thread.
+// Returns ErrNoExecutor if no executor runs for env, because StartExecutor was
+// never called for it or because the environment has shut down and its
+// executor was dropped, and ErrEnvClosed while the environment shuts down.
+func Invoke[T any](ctx context.Context, env Env, fn func(Env) (T, error)) (T, error) {
+	var zero T
+	ex, err := lookupExecutor(env)
+	if err != nil {
+		return zero, err
+	}
+	var res T
+	errc := make(chan error, 1)
+	task := &executorTask{
+		ctx: ctx,
+		run: func(env Env) (err error) {
+			res, err = fn(env)
+			return err
+		},
+		done: func(err error) { errc <- err },
+	}
+	if ex.onMainThread() {
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		ex.exec(env, task)
+	} else if err := ex.post(task); err != nil {
+		return zero, err
+	}
+	select {
+	case err := <-errc:
+		if err != nil {
+			return zero, err
+		}
+		return res, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,218 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// Frozen snapshots
+// Configuration and lookup tables handed to JavaScript must not be mutated by
+// it. Wrapping a Go value with Frozen makes ToValue produce a deep-frozen
+// object graph instead of a mutable one. The result is cached per environment
+// and keyed by the identity of the Go value, its address for pointers, maps
+// and slices and its content for other comparable values, so converting the
+// same value again returns the identical frozen object without any
+// conversion. Cached objects live as long as the environment, and the Go
+// values they were converted from must not change afterwards: Frozen is meant
+// for long-lived, immutable data, not for values built per call.
+// TypedArrays, Buffers included, cannot be frozen and are left writable.
+// Maps and Sets, which Go maps with keys other than strings and sets become,
+// keep their entries mutable once frozen, so they are rejected with an error.
+
+// Frozen function marks v to be converted by ToValue into a deep-frozen,
+// cached snapshot. It can be used anywhere ToValue accepts a value, including
+// inside structs, maps and the arguments of Call. A Value is frozen in place
+// and not cached. The cache pins every value converted, and its frozen object,
+// until the environment shuts down, so values built per call must not be
+// wrapped with Frozen.
+// [in] v: The Go value to convert.
+func Frozen(v interface{}) interface{} {
+	return frozen{v: v}
+}
+
+// frozen is the value returned by Frozen.
+type frozen struct {
+	v interface{}
+}
+
+var frozenType = reflect.TypeOf(frozen{})
+
+// frozenKey identifies a Go value in the cache.
+type frozenKey struct {
+	t   reflect.Type
+	ptr uintptr
+	len int
+	v   interface{}
+}
+
+// frozenEntry is a cached object. v keeps the Go value alive, so that its
+// address cannot be reused by another value while it is a key.
+type frozenEntry struct {
+	ref Ref
+	v   interface{}
+}
+
+var frozenCaches = struct {
+	sync.Mutex
+	m map[Env]map[frozenKey]frozenEntry
+}{m: make(map[Env]map[frozenKey]frozenEntry)}
+
+// DeepFreeze function freezes value and, recursively, the values of all its
+// own properties, as Object.freeze would do at each level. Objects that are
+// already frozen are not walked again, which also stops it on cycles. It fails
+// on Maps and Sets, whose entries cannot be frozen, leaving the objects met
+// before them frozen.
+// [in] env: The environment that the API is invoked under.
+// [in] value: The value to freeze; primitives are left as they are.
+func DeepFreeze(env Env, value Value) error {
+	isFrozen, err := globalValue(env, "Object", "isFrozen")
+	if err != nil {
+		return err
+	}
+	return deepFreeze(env, isFrozen, &collectionCtors{}, value)
+}
+
+func deepFreeze(env Env, isFrozen Value, ctors *collectionCtors, value Value) error {
+	if t, _ := TypeOf(env, value); t != C.napi_object && t != C.napi_function {
+		return nil
+	}
+	if typed, _ := IsTypedArray(env, value); typed {
+		return nil
+	}
+	if err := ctors.resolve(env); err != nil {
+		return err
+	}
+	for _, ctor := range []Value{ctors.mapCtor, ctors.setCtor} {
+		if is, _ := InstanceOf(env, value, ctor); is {
+			return errors.New("napisys: cannot freeze a Map or a Set, whose entries would stay mutable")
+		}
+	}
+	res, err := Call(env, isFrozen, nil, value)
+	if err != nil {
+		return err
+	}
+	if done, _ := GetValueBool(env, res); done {
+		return nil
+	}
+	if status := ObjectFreeze(env, value); status != C.napi_ok {
+		return pendingError(env, status)
+	}
+	names, status := GetAllPropertyNames(env, value, C.napi_key_own_only, C.napi_key_all_properties, C.napi_key_numbers_to_strings)
+	if status != C.napi_ok {
+		return pendingError(env, status)
+	}
+	keys, status := GetElements(env, names)
+	if err := statusError(env, status); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		property, status := GetProperty(env, value, key)
+		if status != C.napi_ok {
+			return pendingError(env, status)
+		}
+		if err := deepFreeze(env, isFrozen, ctors, property); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frozenValue converts v into a deep-frozen object, or returns the one cached
+// for it.
+func frozenValue(env Env, v interface{}) (Value, error) {
+	if value, ok := v.(Value); ok {
+		return value, DeepFreeze(env, value)
+	}
+	key, cacheable := frozenKeyOf(v)
+	if cacheable {
+		if res, ok := lookupFrozen(env, key); ok {
+			return res, nil
+		}
+	}
+	res, err := ToValue(env, v)
+	if err != nil {
+		return nil, err
+	}
+	if err := DeepFreeze(env, res); err != nil {
+		return nil, err
+	}
+	if cacheable {
+		if err := storeFrozen(env, key, frozenEntry{v: v}, res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// frozenKeyOf returns the key of v in the cache, reporting false when v has
+// no identity, for example a struct holding a slice.
+func frozenKeyOf(v interface{}) (frozenKey, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return frozenKey{}, false
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if rv.IsNil() {
+			return frozenKey{}, false
+		}
+		return frozenKey{t: rv.Type(), ptr: rv.Pointer()}, true
+	case reflect.Slice:
+		if rv.IsNil() {
+			return frozenKey{}, false
+		}
+		return frozenKey{t: rv.Type(), ptr: rv.Pointer(), len: rv.Len()}, true
+	}
+	if !rv.Comparable() {
+		return frozenKey{}, false
+	}
+	return frozenKey{t: rv.Type(), v: v}, true
+}
+
+// lookupFrozen returns the object cached for key, if it is still alive.
+func lookupFrozen(env Env, key frozenKey) (Value, bool) {
+	frozenCaches.Lock()
+	entry, ok := frozenCaches.m[env][key]
+	frozenCaches.Unlock()
+	if !ok {
+		return nil, false
+	}
+	res, status := GetReferenceValue(env, entry.ref)
+	return res, status == C.napi_ok && res != nil
+}
+
+// storeFrozen caches res for key. The cache of an environment is dropped when
+// it shuts down.
+func storeFrozen(env Env, key frozenKey, entry frozenEntry, res Value) error {
+	ex, err := executorFor(env)
+	if err != nil {
+		return err
+	}
+	ref, status := CreateReference(env, res, 1)
+	if err := statusError(env, status); err != nil {
+		return err
+	}
+	entry.ref = ref
+	frozenCaches.Lock()
+	defer frozenCaches.Unlock()
+	cache, ok := frozenCaches.m[env]
+	if !ok {
+		cache = make(map[frozenKey]frozenEntry)
+		frozenCaches.m[env] = cache
+		ex.addCloseHook(func(Env) {
+			frozenCaches.Lock()
+			delete(frozenCaches.m, env)
+			frozenCaches.Unlock()
+		})
+	}
+	if old, ok := cache[key]; ok {
+		DeleteReference(env, old.ref)
+	}
+	cache[key] = entry
+	return nil
+}
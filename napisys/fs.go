@@ -0,0 +1,206 @@
+package napisys
+
+/*
+#include <stdlib.h>
+#include <node_api.h>
+*/
+import "C"
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// File systems
+// NewFS exposes an fs.FS, typically an embed.FS holding templates or WASM
+// modules, to JavaScript as an object with synchronous readFile, readdir,
+// stat and exists methods. Files of an embed.FS are immutable, so the first
+// read of each of them copies it once into C memory kept for the life of the
+// process, and readFile returns external Buffers over that memory, with no-op
+// finalizers, instead of new copies. This cache is bounded by the size of the
+// files embedded in the binary. Those Buffers are shared by every read of the
+// file and must not be written to. Files of other file systems, including
+// fs.Sub of an embed.FS, are copied into a new Buffer on each read. Failures
+// are thrown as Errors carrying the code Node.js would use, such as ENOENT.
+
+// embeddedFiles holds the content of the files of an embed.FS read by
+// readFile, in C memory that is never freed.
+var embeddedFiles struct {
+	sync.Mutex
+	m map[embeddedFile][]byte
+}
+
+// embeddedFile identifies a file of an embed.FS.
+type embeddedFile struct {
+	fsys embed.FS
+	name string
+}
+
+// NewFS function creates a JavaScript object giving access to fsys:
+//   - readFile(path) returns the content of a file as a Buffer
+//   - readdir(path) returns the sorted names of the entries of a directory
+//   - stat(path) returns {name, size, mode, mtimeMs, isFile, isDirectory}
+//   - exists(path) returns whether path exists
+//
+// Paths are slash-separated and relative to the root of fsys; a leading slash
+// and "." or ".." elements are resolved first. A path that is not a string
+// throws a TypeError with the code ERR_INVALID_ARG_TYPE.
+// [in] env: The environment that the API is invoked under.
+// [in] fsys: The file system to expose.
+func NewFS(env Env, fsys fs.FS) (Value, error) {
+	methods := map[string]func(env Env, name string) (interface{}, error){
+		"readFile": func(env Env, name string) (interface{}, error) {
+			return readFile(env, fsys, name)
+		},
+		"readdir": func(env Env, name string) (interface{}, error) {
+			entries, err := fs.ReadDir(fsys, name)
+			if err != nil {
+				return nil, fsError("scandir", name, err)
+			}
+			names := make([]string, len(entries))
+			for i, entry := range entries {
+				names[i] = entry.Name()
+			}
+			return names, nil
+		},
+		"stat": func(env Env, name string) (interface{}, error) {
+			info, err := fs.Stat(fsys, name)
+			if err != nil {
+				return nil, fsError("stat", name, err)
+			}
+			return fileStat{
+				Name:        info.Name(),
+				Size:        info.Size(),
+				Mode:        uint32(info.Mode().Perm()),
+				MtimeMs:     float64(info.ModTime().UnixMilli()),
+				IsFile:      info.Mode().IsRegular(),
+				IsDirectory: info.IsDir(),
+			}, nil
+		},
+		"exists": func(env Env, name string) (interface{}, error) {
+			_, err := fs.Stat(fsys, name)
+			return err == nil, nil
+		},
+	}
+	object := make(map[string]interface{}, len(methods))
+	for method, fn := range methods {
+		fn := fn
+		v, err := NewFunction(env, method, func(env Env, args *Args) (interface{}, error) {
+			p, err := fsPath(env, args.At(0))
+			if err != nil {
+				return nil, err
+			}
+			return fn(env, cleanFSPath(p))
+		})
+		if err != nil {
+			return nil, err
+		}
+		object[method] = v
+	}
+	return ToValue(env, object)
+}
+
+// fileStat is the object returned by stat.
+type fileStat struct {
+	Name        string  `json:"name"`
+	Size        int64   `json:"size"`
+	Mode        uint32  `json:"mode"`
+	MtimeMs     float64 `json:"mtimeMs"`
+	IsFile      bool    `json:"isFile"`
+	IsDirectory bool    `json:"isDirectory"`
+}
+
+// fsPath returns the path given to a method, which must be a string.
+func fsPath(env Env, value Value) (string, error) {
+	if t, _ := TypeOf(env, value); t != C.napi_string {
+		return "", &JSError{
+			Name:    "TypeError",
+			Code:    "ERR_INVALID_ARG_TYPE",
+			Message: `The "path" argument must be of type string. Received type ` + typeName(t),
+		}
+	}
+	return stringValue(env, value)
+}
+
+// cleanFSPath turns a path given by JavaScript into a valid fs.FS path.
+func cleanFSPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// readFile returns the content of a file as a Buffer, without copying it
+// again when it is embedded.
+func readFile(env Env, fsys fs.FS, name string) (Value, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, fsError("open", name, err)
+	}
+	if info.IsDir() {
+		return nil, &JSError{Code: "EISDIR", Message: "EISDIR: illegal operation on a directory, read"}
+	}
+	var data []byte
+	if embedded, ok := fsys.(embed.FS); ok {
+		if data, err = embeddedData(embedded, name); err != nil {
+			return nil, fsError("open", name, err)
+		}
+		if len(data) > 0 {
+			raw := unsafe.Pointer(&data[0])
+			if res, status := CreateExternalBuffer(env, uint(len(data)), raw); status == C.napi_ok {
+				return res, nil
+			}
+			// External buffers may be disallowed by the runtime, in which
+			// case the file is copied.
+		}
+	} else if data, err = fs.ReadFile(fsys, name); err != nil {
+		return nil, fsError("open", name, err)
+	}
+	return bytesToValue(env, data)
+}
+
+// embeddedData returns the content of a file of an embed.FS, copying it into
+// C memory the first time it is read.
+func embeddedData(fsys embed.FS, name string) ([]byte, error) {
+	key := embeddedFile{fsys: fsys, name: name}
+	embeddedFiles.Lock()
+	defer embeddedFiles.Unlock()
+	if data, ok := embeddedFiles.m[key]; ok {
+		return data, nil
+	}
+	content, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if len(content) > 0 {
+		data = unsafe.Slice((*byte)(C.malloc(C.size_t(len(content)))), len(content))
+		copy(data, content)
+	}
+	if embeddedFiles.m == nil {
+		embeddedFiles.m = make(map[embeddedFile][]byte)
+	}
+	embeddedFiles.m[key] = data
+	return data, nil
+}
+
+// fsError converts an error of fs into an Error with a Node.js code.
+func fsError(syscall string, name string, err error) error {
+	code, desc := "EIO", "i/o error"
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		code, desc = "ENOENT", "no such file or directory"
+	case errors.Is(err, fs.ErrPermission):
+		code, desc = "EACCES", "permission denied"
+	case errors.Is(err, fs.ErrInvalid):
+		code, desc = "EINVAL", "invalid argument"
+	case strings.Contains(err.Error(), "not a directory"):
+		code, desc = "ENOTDIR", "not a directory"
+	}
+	return &JSError{Code: code, Message: code + ": " + desc + ", " + syscall + " '" + name + "'"}
+}
@@ -0,0 +1,86 @@
+package napisys
+
+/*
+#include "gonapi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"runtime/cgo"
+	"sync"
+)
+
+// Go functions
+// NewFunction exposes a Go closure to JavaScript. Unlike CreateFunction the
+// callback works with Go values: its result is converted with ToValue and an
+// error it returns, or a panic, is thrown as a JavaScript Error. The closure is
+// released once the JavaScript function is garbage collected.
+
+// Func is the signature of the Go functions created with NewFunction. It runs
+// on the main thread. args is reused by later calls, so it must not be kept
+// once the function returns.
+type Func func(env Env, args *Args) (interface{}, error)
+
+// argsPool recycles the Args passed to Funcs, so that a call does not allocate
+// one on the heap for its arguments.
+var argsPool = sync.Pool{New: func() interface{} { return new(Args) }}
+
+// NewFunction function creates a JavaScript function calling fn.
+// [in] env: The environment that the API is invoked under.
+// [in] name: The name of the function, visible as its name property.
+// [in] fn: The Go function to call.
+func NewFunction(env Env, name string, fn Func) (Value, error) {
+	res, status := CreateFunction(env, name, func(env Env, info CallbackInfo) Value {
+		args := argsPool.Get().(*Args)
+		defer releaseArgs(args)
+		var status Status
+		*args, status = GetArgs(env, info)
+		if err := statusError(env, status); err != nil {
+			return args.Throw(err)
+		}
+		return callFunc(env, args, fn)
+	})
+	return res, statusError(env, status)
+}
+
+// releaseArgs clears args, which must not keep Values alive, and returns it to
+// argsPool.
+func releaseArgs(args *Args) {
+	*args = Args{}
+	argsPool.Put(args)
+}
+
+// callFunc runs fn and converts its outcome for JavaScript.
+func callFunc(env Env, args *Args, fn Func) (res Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = args.Throw(fmt.Errorf("napisys: panic in Go function: %v", r))
+		}
+	}()
+	v, err := fn(env, args)
+	if C.IsGoExceptionPending(env) {
+		return nil
+	}
+	if err != nil {
+		return args.Throw(err)
+	}
+	if v == nil {
+		return Value(C.GetGoNull(env))
+	}
+	value, err := ToValue(env, v)
+	if err != nil {
+		return args.Throw(err)
+	}
+	return value
+}
+
+// addFinalizer arranges for fn to be called on the main thread once object is
+// garbage collected.
+func addFinalizer(env Env, object Value, fn func(Env)) error {
+	handle := cgo.NewHandle(fn)
+	var status = Status(C.AddGoFinalizer(env, object, C.uintptr_t(handle)))
+	if status != C.napi_ok {
+		handle.Delete()
+	}
+	return statusError(env, status)
+}
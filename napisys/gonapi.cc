@@ -1,5 +1,10 @@
 #include "gonapi.h"
 
+#include <pthread.h>
+#include <stdlib.h>
+
+#include <vector>
+
 #include "_cgo_export.h"
 
 struct CallbackWrap {
@@ -82,3 +87,217 @@ extern napi_threadsafe_function_call_js ThreadsafeFunctionCallback(void* caller)
   ThreadsafeFunctionCallbackWrap cb{caller};
   return cb();
 }
+
+uintptr_t CurrentThread() {
+  return (uintptr_t) pthread_self();
+}
+
+GoCbArgs GetGoCbArgs(napi_env env, napi_callback_info info) {
+  GoCbArgs args;
+  args.argc = GO_CB_ARGS_INLINE;
+  args.status = napi_get_cb_info(env, info, &args.argc, args.argv,
+      &args.this_arg, &args.data);
+  return args;
+}
+
+bool IsGoExceptionPending(napi_env env) {
+  bool result = false;
+  napi_is_exception_pending(env, &result);
+  return result;
+}
+
+napi_value GetGoNull(napi_env env) {
+  napi_value result = nullptr;
+  napi_get_null(env, &result);
+  return result;
+}
+
+static napi_value GoFunctionCallback(napi_env env, napi_callback_info info) {
+  void* data = nullptr;
+  napi_get_cb_info(env, info, nullptr, nullptr, nullptr, &data);
+  return CallGoFunction(env, info, (uintptr_t) data);
+}
+
+static void GoFunctionFinalize(napi_env env, void* data, void* hint) {
+  FinalizeGoFunction((uintptr_t) data);
+}
+
+napi_status CreateGoFunction(napi_env env,
+                             const char* name,
+                             uintptr_t handle,
+                             napi_value* result) {
+  napi_status status = napi_create_function(env, name, NAPI_AUTO_LENGTH,
+      GoFunctionCallback, (void*) handle, result);
+  if (status != napi_ok) {
+    return status;
+  }
+  return napi_add_finalizer(env, *result, (void*) handle, GoFunctionFinalize,
+      nullptr, nullptr);
+}
+
+static void GoFinalize(napi_env env, void* data, void* hint) {
+  RunGoFinalizer(env, (uintptr_t) data);
+}
+
+napi_status AddGoFinalizer(napi_env env, napi_value object, uintptr_t handle) {
+  return napi_add_finalizer(env, object, (void*) handle, GoFinalize, nullptr,
+      nullptr);
+}
+
+// GoValueFinalize deletes the handle of a Go value once the object holding it
+// is collected, as for functions.
+static void GoValueFinalize(napi_env env, void* data, void* hint) {
+  FinalizeGoFunction((uintptr_t) data);
+}
+
+napi_status WrapGoValue(napi_env env, napi_value object, uintptr_t handle) {
+  return napi_wrap(env, object, (void*) handle, GoValueFinalize, nullptr,
+      nullptr);
+}
+
+napi_status CreateGoExternal(napi_env env,
+                             uintptr_t handle,
+                             napi_value* result) {
+  return napi_create_external(env, (void*) handle, GoValueFinalize, nullptr,
+      result);
+}
+
+static void FreeArrayBuffer(napi_env env, void* data, void* hint) {
+  free(data);
+}
+
+napi_status CreateMallocArrayBuffer(napi_env env,
+                                    size_t length,
+                                    void** data,
+                                    napi_value* result) {
+  // calloc does not accept a length of zero on every platform.
+  *data = calloc(length > 0 ? length : 1, 1);
+  if (*data == nullptr) {
+    return napi_generic_failure;
+  }
+  napi_status status = napi_create_external_arraybuffer(env, *data, length,
+      FreeArrayBuffer, nullptr, result);
+  if (status != napi_ok) {
+    free(*data);
+    *data = nullptr;
+  }
+  return status;
+}
+
+static void GoThreadsafeFunctionCallJS(napi_env env, napi_value callback, void* ctx, void* data) {
+  DispatchGoThreadsafeFunction(env, (uintptr_t) ctx, (uintptr_t) data);
+}
+
+static void GoThreadsafeFunctionFinalize(napi_env env, void* data, void* hint) {
+  FinalizeGoThreadsafeFunction(env, (uintptr_t) hint);
+}
+
+napi_status CreateGoThreadsafeFunction(napi_env env,
+                                       napi_value name,
+                                       uintptr_t handle,
+                                       napi_threadsafe_function* result) {
+  return napi_create_threadsafe_function(env, nullptr, nullptr, name, 0, 1,
+      nullptr, GoThreadsafeFunctionFinalize, (void*) handle,
+      GoThreadsafeFunctionCallJS, result);
+}
+
+napi_status CallGoThreadsafeFunction(napi_threadsafe_function fn,
+                                     uintptr_t data,
+                                     napi_threadsafe_function_call_mode mode) {
+  return napi_call_threadsafe_function(fn, (void*) data, mode);
+}
+
+napi_status NewArrayFromFloat64s(napi_env env,
+                                 const double* values,
+                                 size_t count,
+                                 napi_value* result) {
+  napi_status status = napi_create_array_with_length(env, count, result);
+  for (size_t i = 0; status == napi_ok && i < count; i++) {
+    napi_value element;
+    status = napi_create_double(env, values[i], &element);
+    if (status == napi_ok) {
+      status = napi_set_element(env, *result, (uint32_t) i, element);
+    }
+  }
+  return status;
+}
+
+napi_status NewArrayFromInt32s(napi_env env,
+                               const int32_t* values,
+                               size_t count,
+                               napi_value* result) {
+  napi_status status = napi_create_array_with_length(env, count, result);
+  for (size_t i = 0; status == napi_ok && i < count; i++) {
+    napi_value element;
+    status = napi_create_int32(env, values[i], &element);
+    if (status == napi_ok) {
+      status = napi_set_element(env, *result, (uint32_t) i, element);
+    }
+  }
+  return status;
+}
+
+napi_status NewArrayFromStrings(napi_env env,
+                                const char* data,
+                                const size_t* lengths,
+                                size_t count,
+                                napi_value* result) {
+  napi_status status = napi_create_array_with_length(env, count, result);
+  for (size_t i = 0; status == napi_ok && i < count; i++) {
+    napi_value element;
+    status = napi_create_string_utf8(env, data, lengths[i], &element);
+    if (status == napi_ok) {
+      status = napi_set_element(env, *result, (uint32_t) i, element);
+    }
+    data += lengths[i];
+  }
+  return status;
+}
+
+napi_status NewArrayFromValues(napi_env env,
+                               const napi_value* values,
+                               size_t count,
+                               napi_value* result) {
+  napi_status status = napi_create_array_with_length(env, count, result);
+  for (size_t i = 0; status == napi_ok && i < count; i++) {
+    status = napi_set_element(env, *result, (uint32_t) i, values[i]);
+  }
+  return status;
+}
+
+napi_status SetNamedProperties(napi_env env,
+                               napi_value object,
+                               const char* keys,
+                               const size_t* lengths,
+                               const napi_value* values,
+                               size_t count) {
+  napi_status status = napi_ok;
+  for (size_t i = 0; status == napi_ok && i < count; i++) {
+    napi_value key;
+    status = napi_create_string_utf8(env, keys, lengths[i], &key);
+    if (status == napi_ok) {
+      status = napi_set_property(env, object, key, values[i]);
+    }
+    keys += lengths[i];
+  }
+  return status;
+}
+
+napi_status GetElements(napi_env env,
+                        napi_value array,
+                        napi_value** values,
+                        uint32_t* length) {
+  // The elements are stored in a buffer owned by the calling thread, which
+  // the caller copies before the next call made on that thread.
+  static thread_local std::vector<napi_value> buffer;
+  napi_status status = napi_get_array_length(env, array, length);
+  if (status != napi_ok) {
+    return status;
+  }
+  buffer.resize(*length);
+  for (uint32_t i = 0; status == napi_ok && i < *length; i++) {
+    status = napi_get_element(env, array, i, &buffer[i]);
+  }
+  *values = buffer.data();
+  return status;
+}
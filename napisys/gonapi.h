@@ -1,6 +1,7 @@
 #ifndef GO_NAPI_H
 #define GO_NAPI_H
 
+#include <stdint.h>
 #include <node_api.h>
 
 #ifdef __cplusplus
@@ -13,6 +14,84 @@ extern napi_async_complete_callback AsyncCompleteCallback(void* caller);
 extern napi_finalize FinalizeCallback(void* caller);
 extern napi_threadsafe_function_call_js ThreadsafeFunctionCallback(void* caller);
 
+// node_api_get_module_file_name is part of N-API version 9, which the bundled
+// headers predate.
+NAPI_EXTERN napi_status node_api_get_module_file_name(napi_env env,
+                                                     const char** result);
+
+// GO_CB_ARGS_INLINE is the number of arguments GetGoCbArgs returns by value.
+#define GO_CB_ARGS_INLINE 6
+
+typedef struct {
+  napi_status status;
+  size_t argc;
+  napi_value argv[GO_CB_ARGS_INLINE];
+  napi_value this_arg;
+  void* data;
+} GoCbArgs;
+
+extern uintptr_t CurrentThread();
+extern GoCbArgs GetGoCbArgs(napi_env env, napi_callback_info info);
+// IsGoExceptionPending and GetGoNull return their result by value, so that
+// the Go functions created with NewFunction need no pointer that would escape
+// to the heap.
+extern bool IsGoExceptionPending(napi_env env);
+extern napi_value GetGoNull(napi_env env);
+
+extern napi_status CreateGoThreadsafeFunction(napi_env env,
+                                              napi_value name,
+                                              uintptr_t handle,
+                                              napi_threadsafe_function* result);
+extern napi_status CallGoThreadsafeFunction(napi_threadsafe_function fn,
+                                            uintptr_t data,
+                                            napi_threadsafe_function_call_mode mode);
+
+extern napi_status CreateGoFunction(napi_env env,
+                                    const char* name,
+                                    uintptr_t handle,
+                                    napi_value* result);
+extern napi_status AddGoFinalizer(napi_env env,
+                                  napi_value object,
+                                  uintptr_t handle);
+extern napi_status WrapGoValue(napi_env env,
+                               napi_value object,
+                               uintptr_t handle);
+extern napi_status CreateGoExternal(napi_env env,
+                                    uintptr_t handle,
+                                    napi_value* result);
+extern napi_status CreateMallocArrayBuffer(napi_env env,
+                                           size_t length,
+                                           void** data,
+                                           napi_value* result);
+
+extern napi_status NewArrayFromFloat64s(napi_env env,
+                                        const double* values,
+                                        size_t count,
+                                        napi_value* result);
+extern napi_status NewArrayFromInt32s(napi_env env,
+                                      const int32_t* values,
+                                      size_t count,
+                                      napi_value* result);
+extern napi_status NewArrayFromStrings(napi_env env,
+                                       const char* data,
+                                       const size_t* lengths,
+                                       size_t count,
+                                       napi_value* result);
+extern napi_status NewArrayFromValues(napi_env env,
+                                      const napi_value* values,
+                                      size_t count,
+                                      napi_value* result);
+extern napi_status SetNamedProperties(napi_env env,
+                                      napi_value object,
+                                      const char* keys,
+                                      const size_t* lengths,
+                                      const napi_value* values,
+                                      size_t count);
+extern napi_status GetElements(napi_env env,
+                               napi_value array,
+                               napi_value** values,
+                               uint32_t* length);
+
 #ifdef __cplusplus
 }  // extern "C"
 #endif
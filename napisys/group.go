@@ -0,0 +1,202 @@
+package napisys
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Task groups
+// All, AllSettled, Race and Any run a group of Go tasks concurrently and
+// return a single JavaScript promise settled like the Promise combinator of
+// the same name. Once UseAsyncPool was called the tasks run on the goroutine
+// pool and count against its worker limit; otherwise each task gets its own
+// goroutine. Task results are converted with ToValue. Once the outcome of the
+// group is known, the context given to the tasks is cancelled, so that tasks
+// still running can stop early and tasks not started yet are skipped.
+
+// Task is a unit of work run by a task group.
+type Task func(ctx context.Context) (interface{}, error)
+
+// AggregateError is the rejection reason of Any when every task failed. It is
+// converted to a JavaScript AggregateError.
+type AggregateError struct {
+	Errors []error
+}
+
+func (e *AggregateError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("napisys: all tasks failed: [%s]", strings.Join(msgs, "; "))
+}
+
+type groupMode int
+
+const (
+	groupAll groupMode = iota
+	groupAllSettled
+	groupRace
+	groupAny
+)
+
+// fulfilled and rejected are the settlement objects of AllSettled.
+type fulfilled struct {
+	Status string      `json:"status"`
+	Value  interface{} `json:"value"`
+}
+
+type rejected struct {
+	Status string `json:"status"`
+	Reason error  `json:"reason"`
+}
+
+// All function runs tasks concurrently. The returned promise resolves with
+// the array of their results, in order, or rejects with the first error.
+// It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] tasks: The tasks to run.
+func All(env Env, tasks []Task) (Value, error) {
+	return runGroup(env, tasks, groupAll)
+}
+
+// AllSettled function runs tasks concurrently. The returned promise resolves
+// once all of them are done with an array of {status, value} or
+// {status, reason} objects, in order.
+// It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] tasks: The tasks to run.
+func AllSettled(env Env, tasks []Task) (Value, error) {
+	return runGroup(env, tasks, groupAllSettled)
+}
+
+// Race function runs tasks concurrently. The returned promise settles like
+// the first task that finishes. Without tasks it stays pending forever.
+// It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] tasks: The tasks to run.
+func Race(env Env, tasks []Task) (Value, error) {
+	if len(tasks) == 0 {
+		value, _, status := CreatePromise(env)
+		return value, statusError(env, status)
+	}
+	return runGroup(env, tasks, groupRace)
+}
+
+// Any function runs tasks concurrently. The returned promise resolves with the
+// result of the first task that succeeds, or rejects with an AggregateError
+// once all of them failed.
+// It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] tasks: The tasks to run.
+func Any(env Env, tasks []Task) (Value, error) {
+	return runGroup(env, tasks, groupAny)
+}
+
+// taskGroup collects the outcomes of the tasks of a group.
+type taskGroup struct {
+	mode    groupMode
+	promise *Promise
+	cancel  context.CancelFunc
+
+	mu        sync.Mutex
+	remaining int
+	results   []interface{}
+	errs      []error
+}
+
+func runGroup(env Env, tasks []Task, mode groupMode) (Value, error) {
+	value, promise, err := NewPromise(env)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &taskGroup{
+		mode:      mode,
+		promise:   promise,
+		cancel:    cancel,
+		remaining: len(tasks),
+		results:   make([]interface{}, len(tasks)),
+		errs:      make([]error, len(tasks)),
+	}
+	if len(tasks) == 0 {
+		g.finish()
+		return value, nil
+	}
+	for i, task := range tasks {
+		i, task := i, task
+		run := func() {
+			v, err := runTask(ctx, task)
+			g.done(i, v, err)
+		}
+		if pool.isEnabled() {
+			pool.submit(&poolJob{priority: AsyncPriorityNormal, run: run})
+		} else {
+			go run()
+		}
+	}
+	return value, nil
+}
+
+// runTask runs task unless the group was already cancelled, turning a panic
+// into an error.
+func runTask(ctx context.Context, task Task) (v interface{}, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("napisys: panic in task: %v", r)
+		}
+	}()
+	return task(ctx)
+}
+
+// done records the outcome of the task at index i and settles the promise as
+// soon as the outcome of the group is known.
+func (g *taskGroup) done(i int, v interface{}, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remaining--
+	switch g.mode {
+	case groupAll:
+		if err != nil {
+			g.promise.Reject(err)
+			g.cancel()
+			return
+		}
+		g.results[i] = v
+	case groupAllSettled:
+		if err != nil {
+			g.results[i] = rejected{Status: "rejected", Reason: err}
+		} else {
+			g.results[i] = fulfilled{Status: "fulfilled", Value: v}
+		}
+	case groupRace:
+		g.promise.Settle(v, err)
+		g.cancel()
+		return
+	case groupAny:
+		if err == nil {
+			g.promise.Resolve(v)
+			g.cancel()
+			return
+		}
+		g.errs[i] = err
+	}
+	if g.remaining == 0 {
+		g.finish()
+	}
+}
+
+// finish settles the promise once every task is done.
+func (g *taskGroup) finish() {
+	if g.mode == groupAny {
+		g.promise.Reject(&AggregateError{Errors: g.errs})
+	} else {
+		g.promise.Resolve(g.results)
+	}
+	g.cancel()
+}
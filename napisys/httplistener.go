@@ -0,0 +1,287 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HTTP handlers
+// HTTPListener mounts a net/http Handler inside a Node.js HTTP server, as the
+// request listener of http.createServer or as an Express middleware. The
+// IncomingMessage is converted into an *http.Request whose body streams the
+// request with a StreamReader, and the handler runs on its own goroutine. The
+// status, headers and body written to its ResponseWriter are streamed back to
+// the ServerResponse on the main thread, with the backpressure of
+// StreamWriter. The context of the request is cancelled when the client goes
+// away.
+
+// HTTPListener function creates a JavaScript function (req, res) serving
+// requests with h. It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] h: The handler serving the requests.
+func HTTPListener(env Env, h http.Handler) (Value, error) {
+	if _, err := executorFor(env); err != nil {
+		return nil, err
+	}
+	return NewFunction(env, "listener", func(env Env, args *Args) (interface{}, error) {
+		req, ok := args.Object(0)
+		if !ok {
+			return nil, nil
+		}
+		res, ok := args.Object(1)
+		if !ok {
+			return nil, nil
+		}
+		return nil, serveHTTP(env, h, req, res)
+	})
+}
+
+// serveHTTP converts req and res and starts the handler.
+func serveHTTP(env Env, h http.Handler, req Value, res Value) error {
+	r, err := newHTTPRequest(env, req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r = r.WithContext(ctx)
+	w, err := newHTTPResponse(env, res, cancel)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if r.Body, err = NewStreamReader(env, req); err != nil {
+		cancel()
+		w.body.Close()
+		return err
+	}
+	go func() {
+		defer cancel()
+		defer r.Body.Close()
+		w.serve(h, r)
+	}()
+	return nil
+}
+
+// newHTTPRequest reads the request line and headers of an IncomingMessage.
+func newHTTPRequest(env Env, req Value) (*http.Request, error) {
+	method := stringProperty(env, req, "method")
+	target := stringProperty(env, req, "url")
+	u, err := url.ParseRequestURI(target)
+	if err != nil {
+		return nil, &JSError{Name: "TypeError", Code: "ERR_INVALID_URL", Message: err.Error()}
+	}
+	r := &http.Request{
+		Method:     method,
+		URL:        u,
+		RequestURI: target,
+		Header:     make(http.Header),
+		Proto:      "HTTP/" + stringProperty(env, req, "httpVersion"),
+		Body:       http.NoBody,
+	}
+	var ok bool
+	if r.ProtoMajor, r.ProtoMinor, ok = http.ParseHTTPVersion(r.Proto); !ok {
+		r.ProtoMajor, r.ProtoMinor = 1, 1
+	}
+	raw, status := GetNamedProperty(env, req, "rawHeaders")
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	headers, status := GetElements(env, raw)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		name, _ := stringValue(env, headers[i])
+		value, _ := stringValue(env, headers[i+1])
+		r.Header.Add(name, value)
+	}
+	r.Host = r.Header.Get("Host")
+	r.Header.Del("Host")
+	r.ContentLength = -1
+	if cl := r.Header.Get("Content-Length"); cl != "" {
+		if n, err := strconv.ParseInt(cl, 10, 64); err == nil {
+			r.ContentLength = n
+		}
+	} else if r.Header.Get("Transfer-Encoding") == "" {
+		r.ContentLength = 0
+	}
+	if socket, status := GetNamedProperty(env, req, "socket"); status == C.napi_ok {
+		if t, _ := TypeOf(env, socket); t == C.napi_object {
+			port, _ := GetNamedProperty(env, socket, "remotePort")
+			p, _ := GetValueInt32(env, port)
+			r.RemoteAddr = net.JoinHostPort(stringProperty(env, socket, "remoteAddress"), strconv.Itoa(int(p)))
+		}
+	}
+	return r, nil
+}
+
+// httpResponse is the http.ResponseWriter writing to a ServerResponse.
+type httpResponse struct {
+	env    Env
+	res    Ref
+	off    func(env Env, res Value)
+	body   *StreamWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	err         error
+}
+
+func newHTTPResponse(env Env, res Value, cancel context.CancelFunc) (*httpResponse, error) {
+	body, err := NewStreamWriter(env, res)
+	if err != nil {
+		return nil, err
+	}
+	off, err := onStreamEvents(env, res, map[string]Func{
+		"close": func(env Env, args *Args) (interface{}, error) {
+			cancel()
+			return nil, nil
+		},
+	})
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	ref, status := CreateReference(env, res, 1)
+	if err := statusError(env, status); err != nil {
+		off(env, res)
+		body.Close()
+		return nil, err
+	}
+	return &httpResponse{env: env, res: ref, off: off, body: body, header: make(http.Header)}, nil
+}
+
+// serve runs the handler and ends the response.
+func (w *httpResponse) serve(h http.Handler, r *http.Request) {
+	defer Post(w.env, func(env Env) {
+		if res, status := GetReferenceValue(env, w.res); status == C.napi_ok {
+			w.off(env, res)
+		}
+		DeleteReference(env, w.res)
+	})
+	defer func() {
+		if v := recover(); v != nil {
+			if v == http.ErrAbortHandler {
+				w.destroy(nil)
+				return
+			}
+			err := fmt.Errorf("napisys: panic serving %s: %v", r.URL.Path, v)
+			if w.headerWritten() {
+				w.destroy(err)
+				return
+			}
+			w.header = make(http.Header)
+			w.header.Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.body.Write([]byte(http.StatusText(http.StatusInternalServerError) + "\n"))
+		}
+		w.body.Close()
+	}()
+	h.ServeHTTP(w, r)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Header implements http.ResponseWriter.
+func (w *httpResponse) Header() http.Header {
+	return w.header
+}
+
+// Write implements http.ResponseWriter. Like net/http it sends a status of 200
+// when none was written, detecting the Content-Type from the first bytes when
+// the handler did not set it.
+func (w *httpResponse) Write(p []byte) (int, error) {
+	if !w.headerWritten() {
+		if _, ok := w.header["Content-Type"]; !ok && w.header.Get("Transfer-Encoding") == "" && len(p) > 0 {
+			w.header.Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := w.failure(); err != nil {
+		return 0, err
+	}
+	return w.body.Write(p)
+}
+
+// WriteHeader implements http.ResponseWriter. Only the first call has an
+// effect. A failure to send the headers is returned by the following writes.
+func (w *httpResponse) WriteHeader(code int) {
+	w.mu.Lock()
+	if w.wroteHeader {
+		w.mu.Unlock()
+		return
+	}
+	w.wroteHeader = true
+	w.mu.Unlock()
+	headers := make(map[string][]string, len(w.header))
+	for name, values := range w.header {
+		headers[strings.ToLower(name)] = values
+	}
+	_, err := Invoke(context.Background(), w.env, func(env Env) (struct{}, error) {
+		res, status := GetReferenceValue(env, w.res)
+		if err := statusError(env, status); err != nil {
+			return struct{}{}, err
+		}
+		_, err := CallMethod(env, res, "writeHead", code, headers)
+		return struct{}{}, err
+	})
+	w.fail(err)
+}
+
+// Flush implements http.Flusher. Writes are already sent to the
+// ServerResponse as they happen, so there is nothing to do beyond sending the
+// headers.
+func (w *httpResponse) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *httpResponse) headerWritten() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.wroteHeader
+}
+
+// fail records the first error met while sending the response.
+func (w *httpResponse) fail(err error) {
+	if err == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *httpResponse) failure() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// destroy aborts the response, closing the connection.
+func (w *httpResponse) destroy(cause error) {
+	_, err := Invoke(context.Background(), w.env, func(env Env) (struct{}, error) {
+		res, status := GetReferenceValue(env, w.res)
+		if err := statusError(env, status); err != nil {
+			return struct{}{}, err
+		}
+		var args []interface{}
+		if cause != nil {
+			args = append(args, cause)
+		}
+		_, err := CallMethod(env, res, "destroy", args...)
+		return struct{}{}, err
+	})
+	w.fail(err)
+}
@@ -478,6 +478,49 @@ NAPI_EXTERN napi_status napi_add_finalizer(napi_env env,
 
 #endif  // NAPI_VERSION >= 5
 
+#if NAPI_VERSION >= 6
+
+NAPI_EXTERN napi_status
+napi_get_all_property_names(napi_env env,
+                            napi_value object,
+                            napi_key_collection_mode key_mode,
+                            napi_key_filter key_filter,
+                            napi_key_conversion key_conversion,
+                            napi_value* result);
+
+#endif  // NAPI_VERSION >= 6
+
+#if NAPI_VERSION >= 8
+
+// Object freeze and seal
+NAPI_EXTERN napi_status napi_object_freeze(napi_env env,
+                                           napi_value object);
+NAPI_EXTERN napi_status napi_object_seal(napi_env env,
+                                         napi_value object);
+
+// Type tagging
+NAPI_EXTERN napi_status napi_type_tag_object(napi_env env,
+                                             napi_value value,
+                                             const napi_type_tag* type_tag);
+
+NAPI_EXTERN napi_status
+napi_check_object_type_tag(napi_env env,
+                           napi_value value,
+                           const napi_type_tag* type_tag,
+                           bool* result);
+
+#endif  // NAPI_VERSION >= 8
+
+#if NAPI_VERSION >= 9
+
+// Symbols
+NAPI_EXTERN napi_status node_api_symbol_for(napi_env env,
+                                            const char* utf8description,
+                                            size_t length,
+                                            napi_value* result);
+
+#endif  // NAPI_VERSION >= 9
+
 #ifdef NAPI_EXPERIMENTAL
 
 // BigInt
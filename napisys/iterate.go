@@ -0,0 +1,234 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// Iterating JavaScript iterables
+// IterateJS ranges over a synchronous JavaScript iterable, such as an Array, a
+// Map or a generator, from the main thread. AsyncIterateJS consumes an async
+// iterable, such as a Node.js Readable or an async generator, from a goroutine:
+// it drives next() on the main thread through the executor and sends the
+// values, converted with FromValue, over a channel. Both call return() on the
+// iterator when the Go side stops early, as for...of and for await do.
+
+// IterateJS function returns a sequence over the values of a JavaScript
+// iterable. An error obtaining or advancing the iterator is yielded once,
+// with a nil Value, and ends the sequence. It must be used on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] iterable: The iterable to range over.
+func IterateJS(env Env, iterable Value) iter.Seq2[Value, error] {
+	return func(yield func(Value, error) bool) {
+		it, err := getIterator(env, iterable, SymbolIterator)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for {
+			value, done, err := iteratorStep(env, it)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if done {
+				return
+			}
+			if !yield(value, nil) {
+				closeIterator(env, it)
+				return
+			}
+		}
+	}
+}
+
+// AsyncIterateJS function starts consuming a JavaScript async iterable, or a
+// synchronous one, and returns the channel on which its values are sent. The
+// channel is closed at the end of the iteration; an error, for example a
+// rejection of next(), is sent as the last Result before that. next() is only
+// called once the previous value was received, and the event loop is kept
+// alive until the iteration ends. Cancelling ctx stops the iteration and calls
+// return() on the iterator. It must be called on the main thread.
+// [in] ctx: Context stopping the iteration early.
+// [in] env: The environment that the API is invoked under.
+// [in] iterable: The iterable to consume.
+func AsyncIterateJS(ctx context.Context, env Env, iterable Value) (<-chan Result, error) {
+	it, err := getIterator(env, iterable, SymbolAsyncIterator)
+	if err != nil {
+		return nil, err
+	}
+	ref, status := CreateReference(env, it, 1)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	release, err := KeepAlive(env)
+	if err != nil {
+		DeleteReference(env, ref)
+		return nil, err
+	}
+	ch := make(chan Result)
+	go func() {
+		defer close(ch)
+		stopped := asyncIterate(ctx, env, ref, ch)
+		Post(env, func(env Env) {
+			if stopped {
+				if it, status := GetReferenceValue(env, ref); status == C.napi_ok {
+					closeIterator(env, it)
+				}
+			}
+			DeleteReference(env, ref)
+			release()
+		})
+	}()
+	return ch, nil
+}
+
+// asyncIterate sends the values of the iterator to ch until the iteration
+// ends, reporting true when the Go side stopped it and the iterator must be
+// closed: when ctx is done, or when a result of next() cannot be converted.
+// Unlike a rejection of next(), such a failure leaves the iterator open.
+func asyncIterate(ctx context.Context, env Env, ref Ref, ch chan<- Result) bool {
+	for {
+		next, err := Invoke(ctx, env, func(env Env) (<-chan Result, error) {
+			it, status := GetReferenceValue(env, ref)
+			if err := statusError(env, status); err != nil {
+				return nil, err
+			}
+			res, err := CallMethod(env, it, "next")
+			if err != nil {
+				return nil, err
+			}
+			return Await(env, res), nil
+		})
+		if ctx.Err() != nil {
+			return true
+		}
+		var res Result
+		if err == nil {
+			select {
+			case res = <-next:
+			case <-ctx.Done():
+				return true
+			}
+		}
+		if err == nil && res.Err == nil {
+			var done bool
+			res, done, err = iterResultFromValue(res.Value)
+			if done {
+				return false
+			}
+		}
+		if err != nil {
+			res = Result{Err: err}
+		}
+		select {
+		case ch <- res:
+		case <-ctx.Done():
+			return true
+		}
+		if res.Err != nil {
+			var jsErr *JSError
+			return !errors.As(res.Err, &jsErr)
+		}
+	}
+}
+
+// iterResultFromValue reads a converted {value, done} iterator result.
+func iterResultFromValue(v interface{}) (Result, bool, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return Result{}, false, &JSError{Name: "TypeError", Message: fmt.Sprintf("Iterator result %v is not an object", v)}
+	}
+	done, _ := m["done"].(bool)
+	return Result{Value: m["value"]}, done, nil
+}
+
+// getIterator calls iterable[Symbol[symbol]](). When symbol is asyncIterator
+// and the iterable only implements Symbol.iterator, the synchronous iterator
+// is returned, its results being awaited like for await does.
+func getIterator(env Env, iterable Value, symbol WellKnownSymbol) (Value, error) {
+	method, err := symbolProperty(env, iterable, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if t, _ := TypeOf(env, method); t != C.napi_function && symbol == SymbolAsyncIterator {
+		symbol = SymbolIterator
+		if method, err = symbolProperty(env, iterable, symbol); err != nil {
+			return nil, err
+		}
+	}
+	if t, _ := TypeOf(env, method); t != C.napi_function {
+		return nil, &JSError{Name: "TypeError", Message: "object is not iterable"}
+	}
+	it, err := Call(env, method, iterable)
+	if err != nil {
+		return nil, err
+	}
+	if t, _ := TypeOf(env, it); t != C.napi_object && t != C.napi_function {
+		return nil, &JSError{Name: "TypeError", Message: "Result of the Symbol." + string(symbol) + " method is not an object"}
+	}
+	return it, nil
+}
+
+// symbolProperty returns object[Symbol[symbol]].
+func symbolProperty(env Env, object Value, symbol WellKnownSymbol) (Value, error) {
+	key, err := WellKnown(env, symbol)
+	if err != nil {
+		return nil, err
+	}
+	object, status := CoerceToObject(env, object)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	res, status := GetProperty(env, object, key)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return res, nil
+}
+
+// iteratorStep calls it.next() and unpacks the result.
+func iteratorStep(env Env, it Value) (Value, bool, error) {
+	res, err := CallMethod(env, it, "next")
+	if err != nil {
+		return nil, false, err
+	}
+	if t, _ := TypeOf(env, res); t != C.napi_object && t != C.napi_function {
+		return nil, false, &JSError{Name: "TypeError", Message: "Iterator result is not an object"}
+	}
+	done, status := GetNamedProperty(env, res, "done")
+	if status != C.napi_ok {
+		return nil, false, pendingError(env, status)
+	}
+	isDone, status := CoerceToBool(env, done)
+	if status != C.napi_ok {
+		return nil, false, pendingError(env, status)
+	}
+	if b, _ := GetValueBool(env, isDone); b {
+		return nil, true, nil
+	}
+	value, status := GetNamedProperty(env, res, "value")
+	if status != C.napi_ok {
+		return nil, false, pendingError(env, status)
+	}
+	return value, false, nil
+}
+
+// closeIterator calls it.return() when the iterator has such a method. Its
+// outcome is ignored, as the caller already decided to stop.
+func closeIterator(env Env, it Value) {
+	ret, status := GetNamedProperty(env, it, "return")
+	if status != C.napi_ok {
+		pendingError(env, status)
+		return
+	}
+	if t, _ := TypeOf(env, ret); t == C.napi_function {
+		Call(env, ret, it)
+	}
+}
@@ -0,0 +1,71 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// JSON
+// FromJSONBytes and ToJSONBytes move whole documents between Go and
+// JavaScript with the JSON.parse and JSON.stringify functions of the engine.
+// A document then crosses the boundary as a single string instead of being
+// walked property by property, which is much faster for large documents
+// already encoded by encoding/json. json.RawMessage values given to ToValue
+// take the same path.
+
+// ErrNotJSON is returned by ToJSONBytes for values JSON.stringify does not
+// serialize, such as undefined, functions and symbols.
+var ErrNotJSON = errors.New("napisys: value cannot be represented as JSON")
+
+// FromJSONBytes function parses a JSON document with JSON.parse.
+// [in] env: The environment that the API is invoked under.
+// [in] data: The UTF-8 encoded JSON document.
+// Returns a SyntaxError *JSError if data is not valid JSON.
+func FromJSONBytes(env Env, data []byte) (Value, error) {
+	// The string is copied by the engine, data does not need to be copied
+	// first.
+	text, err := stringToValue(env, unsafe.String(unsafe.SliceData(data), len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return callJSON(env, "parse", text)
+}
+
+// ToJSONBytes function serializes a value with JSON.stringify.
+// [in] env: The environment that the API is invoked under.
+// [in] value: The JavaScript value to serialize.
+// Returns ErrNotJSON if the value has no JSON representation, and the
+// exception thrown by JSON.stringify, such as the TypeError of cyclic
+// structures, as a *JSError.
+func ToJSONBytes(env Env, value Value) ([]byte, error) {
+	res, err := callJSON(env, "stringify", value)
+	if err != nil {
+		return nil, err
+	}
+	if t, _ := TypeOf(env, res); t != C.napi_string {
+		return nil, ErrNotJSON
+	}
+	return stringBytes(env, res)
+}
+
+// callJSON calls the method name of the global JSON object with a single
+// argument.
+func callJSON(env Env, name string, arg Value) (Value, error) {
+	json, err := globalValue(env, "JSON")
+	if err != nil {
+		return nil, err
+	}
+	fn, status := GetNamedProperty(env, json, name)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	res, status := CallFunction(env, json, fn, []Value{arg})
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return res, nil
+}
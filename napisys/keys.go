@@ -0,0 +1,81 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import "iter"
+
+// Property enumeration
+// Keys and Entries read the properties of an object into Go, sparing callers
+// the walk of the Array returned by GetPropertyNames or GetAllPropertyNames.
+// Keys selects properties with a KeyCollectionMode and a KeyFilter, for
+// example only the writable or configurable ones, while Entries mirrors
+// Object.entries. Both skip symbol keys and return the keys of indexed
+// properties as strings. Use GetAllPropertyNames to include symbol keys.
+
+// Keys function returns the string keys of the properties of object that
+// match filter, in the order of Object.keys, own properties first.
+// [in] env: The environment that the API is invoked under.
+// [in] object: The object whose properties to list.
+// [in] mode: Whether to include the properties of the prototype chain (see
+// KeyCollectionModes).
+// [in] filter: A combination of KeyFilters, for example
+// KeyFilter(KeyFilters.Enumerable | KeyFilters.Writable).
+func Keys(env Env, object Value, mode KeyCollectionMode, filter KeyFilter) ([]string, error) {
+	names, status := GetAllPropertyNames(env, object, mode, filter|C.napi_key_skip_symbols, C.napi_key_numbers_to_strings)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	keys, status := GetElements(env, names)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	res := make([]string, len(keys))
+	for i, key := range keys {
+		name, err := stringValue(env, key)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = name
+	}
+	return res, nil
+}
+
+// Entries function returns a sequence over the own enumerable string-keyed
+// properties of object, as Object.entries. The keys are listed when the
+// sequence starts and each value is read when it is reached, so it may run
+// getters. A failure, such as a throwing getter, ends the sequence and is left
+// as a pending exception, which callers detect with IsExceptionPending or let
+// propagate by returning to JavaScript. It must be used on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] object: The object whose properties to range over.
+func Entries(env Env, object Value) iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		names, status := GetAllPropertyNames(env, object, C.napi_key_own_only, C.napi_key_enumerable|C.napi_key_skip_symbols, C.napi_key_numbers_to_strings)
+		if status != C.napi_ok {
+			throwStatus(env, status)
+			return
+		}
+		keys, status := GetElements(env, names)
+		if status != C.napi_ok {
+			throwStatus(env, status)
+			return
+		}
+		for _, key := range keys {
+			value, status := GetProperty(env, object, key)
+			if status != C.napi_ok {
+				throwStatus(env, status)
+				return
+			}
+			name, err := stringValue(env, key)
+			if err != nil {
+				throwError(env, err)
+				return
+			}
+			if !yield(name, value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package napisys
+
+/*
+#include "gonapi.h"
+*/
+import "C"
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// Shared binary layouts
+// A Layout describes a fixed-size record once, as a Go struct packed the way
+// encoding/binary packs it: fields follow each other without padding, in the
+// byte order of the layout, and blank (_) fields reserve space. From it Go
+// gets Records, a view decoding and encoding records in place in the memory
+// of an ArrayBuffer, and JavaScript gets a generated class whose getters and
+// setters read and write the same bytes through a DataView. Both sides then
+// share packed records without serializing them across the boundary.
+// Fields may be booleans, sized integers, floats and arrays of those; int,
+// uint, strings, slices and nested structs have no fixed size and are
+// rejected. JavaScript names fields after their `json` tag, or their Go name;
+// fields tagged "-" keep their space but are not exposed. Names must be unique,
+// and "constructor" and "__proto__", which have a meaning of their own in a
+// class, are rejected. 64-bit integers are
+// BigInts in JavaScript, arrays of bytes are Uint8Array or Int8Array views of
+// the record and other arrays are copied into and from Arrays.
+
+// Layout is the layout of the records of type T.
+type Layout[T any] struct {
+	order  binary.ByteOrder
+	size   int
+	fields []layoutField
+}
+
+// layoutField is a field exposed to JavaScript.
+type layoutField struct {
+	name   string
+	offset int
+	kind   reflect.Kind
+	count  int // number of elements of an array, 0 for scalars
+}
+
+// layoutTypes gives the DataView accessor and the size of the kinds a layout
+// supports.
+var layoutTypes = map[reflect.Kind]struct {
+	accessor string
+	size     int
+}{
+	reflect.Bool:    {"Uint8", 1},
+	reflect.Int8:    {"Int8", 1},
+	reflect.Uint8:   {"Uint8", 1},
+	reflect.Int16:   {"Int16", 2},
+	reflect.Uint16:  {"Uint16", 2},
+	reflect.Int32:   {"Int32", 4},
+	reflect.Uint32:  {"Uint32", 4},
+	reflect.Int64:   {"BigInt64", 8},
+	reflect.Uint64:  {"BigUint64", 8},
+	reflect.Float32: {"Float32", 4},
+	reflect.Float64: {"Float64", 8},
+}
+
+// NewLayout function computes the layout of the records of type T, which must
+// be a struct.
+// [in] order: The byte order of the fields, binary.LittleEndian or
+// binary.BigEndian.
+func NewLayout[T any](order binary.ByteOrder) (*Layout[T], error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("napisys: cannot lay out %s, only structs have a layout", t)
+	}
+	l := &Layout[T]{order: order}
+	names := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		ft, count := sf.Type, 0
+		if ft.Kind() == reflect.Array {
+			ft, count = ft.Elem(), ft.Len()
+		}
+		typ, ok := layoutTypes[ft.Kind()]
+		if !ok {
+			return nil, fmt.Errorf("napisys: cannot lay out field %s of type %s", sf.Name, sf.Type)
+		}
+		if sf.Name != "_" && !sf.IsExported() {
+			return nil, fmt.Errorf("napisys: cannot lay out unexported field %s", sf.Name)
+		}
+		name := sf.Name
+		if tag := sf.Tag.Get("json"); tag != "" {
+			name, _, _ = strings.Cut(tag, ",")
+			if name == "" {
+				name = sf.Name
+			}
+		}
+		if sf.Name != "_" && name != "-" {
+			if name == "constructor" || name == "__proto__" {
+				return nil, fmt.Errorf("napisys: cannot lay out field %s named %q, which is reserved in a JavaScript class", sf.Name, name)
+			}
+			if other, ok := names[name]; ok {
+				return nil, fmt.Errorf("napisys: cannot lay out field %s named %q, the name of field %s", sf.Name, name, other)
+			}
+			names[name] = sf.Name
+			l.fields = append(l.fields, layoutField{name: name, offset: l.size, kind: ft.Kind(), count: count})
+		}
+		l.size += typ.size * max(count, 1)
+	}
+	if n := binary.Size(new(T)); n != l.size {
+		return nil, fmt.Errorf("napisys: cannot lay out %s", t)
+	}
+	return l, nil
+}
+
+// Size returns the size in bytes of a record.
+func (l *Layout[T]) Size() int {
+	return l.size
+}
+
+// Class function creates the JavaScript class accessing records of this
+// layout. new Class(buffer, byteOffset = 0) accesses the record at byteOffset
+// in an ArrayBuffer, and Class.at(buffer, index) the record at index; each
+// field is an accessor property of the instances. Class.BYTES_PER_RECORD is
+// the size of a record and Class.fields gives the offset and type of each
+// field.
+// [in] env: The environment that the API is invoked under.
+// [in] name: The name of the class.
+func (l *Layout[T]) Class(env Env, name string) (Value, error) {
+	source, err := l.classSource(name)
+	if err != nil {
+		return nil, err
+	}
+	script, err := stringToValue(env, source)
+	if err != nil {
+		return nil, err
+	}
+	res, status := RunScript(env, script)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return res, nil
+}
+
+// classSource generates the source of the class accessing the records.
+func (l *Layout[T]) classSource(name string) (string, error) {
+	quote := func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	le := "false"
+	if l.order == binary.LittleEndian {
+		le = "true"
+	} else if l.order != binary.BigEndian {
+		return "", fmt.Errorf("napisys: cannot generate a class for the byte order %s", l.order)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "(function () {\n'use strict';\nconst SIZE = %d;\nconst LE = %s;\n", l.size, le)
+	b.WriteString("class Record {\n#view;\n")
+	b.WriteString("constructor(buffer, byteOffset = 0) { this.#view = new DataView(buffer, byteOffset, SIZE); }\n")
+	b.WriteString("static at(buffer, index) { return new this(buffer, index * SIZE); }\n")
+	fields := make([]string, len(l.fields))
+	for i, f := range l.fields {
+		typ := layoutTypes[f.kind]
+		get := fmt.Sprintf("this.#view.get%s(%%s, LE)", typ.accessor)
+		set := fmt.Sprintf("this.#view.set%s(%%s, %%s, LE)", typ.accessor)
+		value := "v"
+		if f.kind == reflect.Bool {
+			get = "(" + get + " !== 0)"
+			value = "(v ? 1 : 0)"
+		}
+		key := quote(f.name)
+		typeName := strings.ToLower(typ.accessor)
+		switch {
+		case f.count == 0:
+			fmt.Fprintf(&b, "get %s() { return %s; }\n", key, fmt.Sprintf(get, fmt.Sprint(f.offset)))
+			fmt.Fprintf(&b, "set %s(v) { %s; }\n", key, fmt.Sprintf(set, fmt.Sprint(f.offset), value))
+		case f.kind == reflect.Uint8 || f.kind == reflect.Int8:
+			array := "Uint8Array"
+			if f.kind == reflect.Int8 {
+				array = "Int8Array"
+			}
+			fmt.Fprintf(&b, "get %s() { return new %s(this.#view.buffer, this.#view.byteOffset + %d, %d); }\n", key, array, f.offset, f.count)
+			fmt.Fprintf(&b, "set %s(v) { this[%s].set(v); }\n", key, key)
+			typeName = fmt.Sprintf("%s[%d]", typeName, f.count)
+		default:
+			offset := fmt.Sprintf("%d + i * %d", f.offset, typ.size)
+			fmt.Fprintf(&b, "get %s() { const a = new Array(%d); for (let i = 0; i < %d; i++) a[i] = %s; return a; }\n",
+				key, f.count, f.count, fmt.Sprintf(get, offset))
+			fmt.Fprintf(&b, "set %s(a) { for (let i = 0; i < %d; i++) { const v = a[i]; %s; } }\n",
+				key, f.count, fmt.Sprintf(set, offset, value))
+			typeName = fmt.Sprintf("%s[%d]", typeName, f.count)
+		}
+		if f.kind == reflect.Bool {
+			typeName = strings.Replace(typeName, "uint8", "bool", 1)
+		}
+		fields[i] = fmt.Sprintf("%s: Object.freeze({ offset: %d, type: %s })", key, f.offset, quote(typeName))
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "Object.defineProperty(Record, 'name', { value: %s });\n", quote(name))
+	b.WriteString("Object.defineProperty(Record, 'BYTES_PER_RECORD', { value: SIZE });\n")
+	fmt.Fprintf(&b, "Object.defineProperty(Record, 'fields', { value: Object.freeze({ %s }) });\n", strings.Join(fields, ", "))
+	b.WriteString("return Record;\n})()")
+	return b.String(), nil
+}
+
+// View function returns the records of this layout stored in an ArrayBuffer
+// from byteOffset, as many as fit.
+// [in] env: The environment that the API is invoked under.
+// [in] buffer: The ArrayBuffer holding the records.
+// [in] byteOffset: The offset of the first record in the ArrayBuffer.
+// The Records point into memory owned by JavaScript and must only be used
+// while the ArrayBuffer is reachable and not detached.
+func (l *Layout[T]) View(env Env, buffer Value, byteOffset int) (*Records[T], error) {
+	if ok, _ := IsArrayBuffer(env, buffer); !ok {
+		return nil, &JSError{Name: "TypeError", Code: "ERR_INVALID_ARG_TYPE", Message: "The value must be an ArrayBuffer"}
+	}
+	data, length, status := GetArrayBufferInfo(env, buffer)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	if byteOffset < 0 || byteOffset > int(length) {
+		return nil, errOutOfBounds
+	}
+	r := &Records[T]{layout: l}
+	if count := (int(length) - byteOffset) / l.size; count > 0 {
+		r.mem = unsafe.Slice((*byte)(unsafe.Add(data, byteOffset)), count*l.size)
+	}
+	return r, nil
+}
+
+// NewBuffer function allocates an external ArrayBuffer holding count zeroed
+// records and returns it along with their Go view. The memory is allocated
+// outside of both heaps and freed once the ArrayBuffer is garbage collected.
+// [in] env: The environment that the API is invoked under.
+// [in] count: The number of records.
+func (l *Layout[T]) NewBuffer(env Env, count int) (Value, *Records[T], error) {
+	var res C.napi_value
+	var data unsafe.Pointer
+	var status = Status(C.CreateMallocArrayBuffer(env, C.size_t(count*l.size), &data, &res))
+	if status != C.napi_ok {
+		// External ArrayBuffers may be disallowed by the runtime, in which case
+		// the memory is allocated by the engine.
+		if pending, _ := IsExceptionPending(env); pending {
+			return nil, nil, pendingError(env, status)
+		}
+		var buffer Value
+		buffer, data, status = CreateArrayBuffer(env, uint(count*l.size))
+		if err := statusError(env, status); err != nil {
+			return nil, nil, err
+		}
+		res = buffer
+	}
+	r := &Records[T]{layout: l}
+	if count > 0 {
+		r.mem = unsafe.Slice((*byte)(data), count*l.size)
+	}
+	return Value(res), r, nil
+}
+
+// Records is a Go view over records stored in the memory of an ArrayBuffer.
+type Records[T any] struct {
+	layout *Layout[T]
+	mem    []byte
+}
+
+// Len returns the number of records.
+func (r *Records[T]) Len() int {
+	return len(r.mem) / r.layout.size
+}
+
+// Bytes returns the memory of the records.
+func (r *Records[T]) Bytes() []byte {
+	return r.mem
+}
+
+// Load decodes the record at index i.
+func (r *Records[T]) Load(i int) (T, error) {
+	var v T
+	b, err := r.record(i)
+	if err != nil {
+		return v, err
+	}
+	_, err = binary.Decode(b, r.layout.order, &v)
+	return v, err
+}
+
+// Store encodes v as the record at index i.
+func (r *Records[T]) Store(i int, v T) error {
+	b, err := r.record(i)
+	if err != nil {
+		return err
+	}
+	_, err = binary.Encode(b, r.layout.order, &v)
+	return err
+}
+
+func (r *Records[T]) record(i int) ([]byte, error) {
+	if i < 0 || i >= r.Len() {
+		return nil, &JSError{Name: "RangeError", Code: "ERR_OUT_OF_RANGE", Message: fmt.Sprintf("Record index %d is out of range", i)}
+	}
+	return r.mem[i*r.layout.size : (i+1)*r.layout.size], nil
+}
@@ -0,0 +1,564 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Marshaling
+// ToValue converts Go values into JavaScript values following rules close to
+// the ones of encoding/json:
+//  - nil, nil pointers, nil maps and nil slices become null
+//  - a Value is returned as is
+//  - booleans, numbers and strings become their JavaScript counterpart
+//  - []byte becomes a Buffer holding a copy of the bytes
+//  - json.RawMessage is parsed with JSON.parse (see FromJSONBytes)
+//  - a value wrapped with Frozen becomes a deep-frozen, cached snapshot
+//  - an error becomes an Error (see JSError)
+//  - slices and arrays become Arrays
+//  - maps with string keys and structs become Objects; struct fields honour
+//    the `json` tag for their name, "-" and omitempty
+//  - maps with other keys become Maps and map[T]struct{} becomes a Set (see
+//    ToMap)
+// Arrays and objects are built with the bulk helpers, so each of them costs a
+// single transition to C once its elements have been converted.
+// FromValue converts the other way round, producing the same Go types
+// encoding/json would give to interface{} values, plus []byte for Buffers and
+// Uint8Arrays, []int32 and []float64 for Int32Arrays and Float64Arrays, and
+// *JSError for Errors, and map[interface{}]interface{} and
+// map[interface{}]struct{} for Maps and Sets.
+
+var valueType = reflect.TypeOf(Value(nil))
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// ToValue function converts a Go value into a JavaScript value.
+// [in] env: The environment that the API is invoked under.
+// [in] v: The Go value to convert.
+func ToValue(env Env, v interface{}) (Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return nullValue(env)
+	case Value:
+		if v == nil {
+			return undefinedValue(env)
+		}
+		return v, nil
+	case bool:
+		res, status := GetBoolean(env, v)
+		return res, statusError(env, status)
+	case string:
+		return stringToValue(env, v)
+	case int:
+		res, status := CreateInt64(env, int64(v))
+		return res, statusError(env, status)
+	case int32:
+		res, status := CreateInt32(env, v)
+		return res, statusError(env, status)
+	case int64:
+		res, status := CreateInt64(env, v)
+		return res, statusError(env, status)
+	case float64:
+		res, status := CreateDouble(env, v)
+		return res, statusError(env, status)
+	case frozen:
+		return frozenValue(env, v.v)
+	case json.RawMessage:
+		if v == nil {
+			return nullValue(env)
+		}
+		return FromJSONBytes(env, v)
+	case []byte:
+		return bytesToValue(env, v)
+	case []float64:
+		res, status := NewArrayFromFloat64s(env, v)
+		return res, statusError(env, status)
+	case []int32:
+		res, status := NewArrayFromInt32s(env, v)
+		return res, statusError(env, status)
+	case []string:
+		res, status := NewArrayFromStrings(env, v)
+		return res, statusError(env, status)
+	case error:
+		return errorValue(env, v)
+	}
+	return reflectToValue(env, reflect.ValueOf(v), &toValueState{})
+}
+
+// toValues converts each of args with ToValue.
+func toValues(env Env, args []interface{}) ([]Value, error) {
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		v, err := ToValue(env, arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// maxToValueDepth bounds the nesting ToValue accepts.
+const maxToValueDepth = 1000
+
+// startDetectingCyclesAfter is the depth from which ToValue remembers the
+// pointers, maps and slices it goes through, so that it reports cycles with a
+// clear error without paying for that bookkeeping on shallow values, as
+// encoding/json does.
+const startDetectingCyclesAfter = 100
+
+// toValueState tracks the path ToValue follows through a Go value.
+type toValueState struct {
+	depth int
+	seen  map[cycleKey]struct{}
+}
+
+// cycleKey identifies a pointer, map or slice on the path followed by ToValue.
+type cycleKey struct {
+	typ reflect.Type
+	ptr unsafe.Pointer
+	len int
+}
+
+func tracksCycles(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+func keyOf(rv reflect.Value) cycleKey {
+	key := cycleKey{typ: rv.Type(), ptr: rv.UnsafePointer()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+	return key
+}
+
+// enter records that ToValue goes one level deeper through rv, and fails if
+// that is too deep or if rv is already being converted.
+func (s *toValueState) enter(rv reflect.Value) error {
+	s.depth++
+	if s.depth > maxToValueDepth {
+		return fmt.Errorf("napisys: value nested more than %d levels deep", maxToValueDepth)
+	}
+	if s.depth <= startDetectingCyclesAfter || !tracksCycles(rv) {
+		return nil
+	}
+	key := keyOf(rv)
+	if _, ok := s.seen[key]; ok {
+		return fmt.Errorf("napisys: cannot convert %s to a JavaScript value: encountered a cycle", rv.Type())
+	}
+	if s.seen == nil {
+		s.seen = make(map[cycleKey]struct{})
+	}
+	s.seen[key] = struct{}{}
+	return nil
+}
+
+// leave undoes what a successful call to enter did for rv.
+func (s *toValueState) leave(rv reflect.Value) {
+	if s.depth > startDetectingCyclesAfter && tracksCycles(rv) {
+		delete(s.seen, keyOf(rv))
+	}
+	s.depth--
+}
+
+func reflectToValue(env Env, rv reflect.Value, s *toValueState) (Value, error) {
+	if rv.Type() == valueType || rv.Type() == rawMessageType || rv.Type() == frozenType {
+		return ToValue(env, rv.Interface())
+	}
+	if (rv.Kind() == reflect.Interface || rv.Kind() == reflect.Ptr) && rv.IsNil() {
+		return nullValue(env)
+	}
+	if rv.Kind() == reflect.Interface {
+		return reflectToValue(env, rv.Elem(), s)
+	}
+	if rv.Type().Implements(errorType) {
+		return errorValue(env, rv.Interface().(error))
+	}
+	if (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return nullValue(env)
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		if err := s.enter(rv); err != nil {
+			return nil, err
+		}
+		defer s.leave(rv)
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		return ToValue(env, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ToValue(env, rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return ToValue(env, float64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return ToValue(env, rv.Float())
+	case reflect.String:
+		return stringToValue(env, rv.String())
+	case reflect.Ptr:
+		return reflectToValue(env, rv.Elem(), s)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return bytesToValue(env, rv.Bytes())
+		}
+		return sliceToValue(env, rv, s)
+	case reflect.Array:
+		return sliceToValue(env, rv, s)
+	case reflect.Map:
+		return mapToValue(env, rv, s)
+	case reflect.Struct:
+		return structToValue(env, rv, s)
+	}
+	return nil, fmt.Errorf("napisys: cannot convert %s to a JavaScript value", rv.Type())
+}
+
+func sliceToValue(env Env, rv reflect.Value, s *toValueState) (Value, error) {
+	values := make([]Value, rv.Len())
+	for i := range values {
+		v, err := reflectToValue(env, rv.Index(i), s)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	res, status := NewArrayFromValues(env, values)
+	return res, statusError(env, status)
+}
+
+func mapToValue(env Env, rv reflect.Value, s *toValueState) (Value, error) {
+	if isSetType(rv.Type()) {
+		return setToValue(env, rv, s)
+	}
+	if rv.Type().Key().Kind() != reflect.String {
+		return mapToJSMap(env, rv, s)
+	}
+	keys := make([]string, 0, rv.Len())
+	values := make([]Value, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		v, err := reflectToValue(env, iter.Value(), s)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, iter.Key().String())
+		values = append(values, v)
+	}
+	return newObject(env, keys, values)
+}
+
+func structToValue(env Env, rv reflect.Value, s *toValueState) (Value, error) {
+	fields := structFields(rv.Type())
+	keys := make([]string, 0, len(fields))
+	values := make([]Value, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && fv.IsZero()) {
+			continue
+		}
+		v, err := reflectToValue(env, fv, s)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, f.name)
+		values = append(values, v)
+	}
+	return newObject(env, keys, values)
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead of
+// panicking when it goes through a nil embedded pointer.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// field describes how a struct field is marshaled.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// structFields returns the marshaled fields of a struct type. Fields of
+// embedded structs without a name in their tag are promoted, like
+// encoding/json does.
+func structFields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for _, inner := range structFields(ft) {
+				inner.index = append([]int{i}, inner.index...)
+				fields = append(fields, inner)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// maxFromValueDepth bounds the nesting FromValue accepts, which also stops it
+// on cyclic objects.
+const maxFromValueDepth = 1000
+
+// FromValue function converts a JavaScript value into a Go value. undefined
+// and null become nil; Booleans, Numbers and Strings become bool, float64 and
+// string; BigInts that fit become int64; Errors become *JSError; Arrays become
+// []interface{}; Maps and Sets become map[interface{}]interface{} and
+// map[interface{}]struct{}, in which case their keys must be primitives; other
+// Objects become map[string]interface{}, built from their enumerable string
+// keys.
+// [in] env: The environment that the API is invoked under.
+// [in] value: The JavaScript value to convert.
+func FromValue(env Env, value Value) (interface{}, error) {
+	return fromValue(env, value, 0, &collectionCtors{})
+}
+
+func fromValue(env Env, value Value, depth int, ctors *collectionCtors) (interface{}, error) {
+	if depth > maxFromValueDepth {
+		return nil, fmt.Errorf("napisys: value nested more than %d levels deep", maxFromValueDepth)
+	}
+	t, status := TypeOf(env, value)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	switch t {
+	case C.napi_undefined, C.napi_null:
+		return nil, nil
+	case C.napi_boolean:
+		res, status := GetValueBool(env, value)
+		return res, statusError(env, status)
+	case C.napi_number:
+		res, status := GetValueDouble(env, value)
+		return res, statusError(env, status)
+	case C.napi_string:
+		return stringValue(env, value)
+	case C.napi_bigint:
+		res, lossless, status := GetValueBigintInt64(env, value)
+		if err := statusError(env, status); err != nil {
+			return nil, err
+		}
+		if !lossless {
+			return nil, fmt.Errorf("napisys: BigInt does not fit in an int64")
+		}
+		return res, nil
+	case C.napi_object:
+		return objectFromValue(env, value, depth, ctors)
+	}
+	return nil, fmt.Errorf("napisys: cannot convert a JavaScript %s to a Go value", typeName(t))
+}
+
+func objectFromValue(env Env, value Value, depth int, ctors *collectionCtors) (interface{}, error) {
+	if isError, _ := IsError(env, value); isError {
+		return exceptionError(env, value), nil
+	}
+	if isTyped, _ := IsTypedArray(env, value); isTyped {
+		return typedArrayFromValue(env, value)
+	}
+	if isArray, _ := IsArray(env, value); isArray {
+		elements, status := GetElements(env, value)
+		if err := statusError(env, status); err != nil {
+			return nil, err
+		}
+		res := make([]interface{}, len(elements))
+		for i, element := range elements {
+			v, err := fromValue(env, element, depth+1, ctors)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = v
+		}
+		return res, nil
+	}
+	if res, ok, err := collectionFromValue(env, value, depth, ctors); ok {
+		return res, err
+	}
+	names, status := GetPropertyNames(env, value)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	keys, status := GetElements(env, names)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	res := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		name, err := stringValue(env, key)
+		if err != nil {
+			return nil, err
+		}
+		property, status := GetProperty(env, value, key)
+		if err := statusError(env, status); err != nil {
+			return nil, err
+		}
+		v, err := fromValue(env, property, depth+1, ctors)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = v
+	}
+	return res, nil
+}
+
+// typedArrayFromValue copies the content of a TypedArray into a Go slice.
+func typedArrayFromValue(env Env, value Value) (interface{}, error) {
+	_, kind, length, data, _, status := GetTypedArrayInfo(env, value)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		data = nil
+	}
+	switch kind {
+	case C.napi_uint8_array, C.napi_uint8_clamped_array:
+		if data == nil {
+			return []byte{}, nil
+		}
+		return append([]byte(nil), unsafe.Slice((*byte)(data), length)...), nil
+	case C.napi_int32_array:
+		if data == nil {
+			return []int32{}, nil
+		}
+		return append([]int32(nil), unsafe.Slice((*int32)(data), length)...), nil
+	case C.napi_float64_array:
+		if data == nil {
+			return []float64{}, nil
+		}
+		return append([]float64(nil), unsafe.Slice((*float64)(data), length)...), nil
+	}
+	return nil, fmt.Errorf("napisys: cannot convert this TypedArray to a Go value")
+}
+
+func newObject(env Env, keys []string, values []Value) (Value, error) {
+	res, status := CreateObject(env)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	if err := statusError(env, SetNamedProperties(env, res, keys, values)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func nullValue(env Env) (Value, error) {
+	res, status := GetNull(env)
+	return res, statusError(env, status)
+}
+
+func undefinedValue(env Env) (Value, error) {
+	res, status := GetUndefined(env)
+	return res, statusError(env, status)
+}
+
+// stringToValue creates a JavaScript String from s. Unlike CreateStringUtf8 it
+// passes the length explicitly, so s may contain NUL bytes.
+func stringToValue(env Env, s string) (Value, error) {
+	var res C.napi_value
+	var str *C.char
+	if len(s) > 0 {
+		str = (*C.char)(unsafe.Pointer(unsafe.StringData(s)))
+	}
+	var status = C.napi_create_string_utf8(env, str, C.size_t(len(s)), &res)
+	return Value(res), statusError(env, Status(status))
+}
+
+func bytesToValue(env Env, b []byte) (Value, error) {
+	var raw unsafe.Pointer
+	if len(b) > 0 {
+		raw = unsafe.Pointer(&b[0])
+	}
+	res, _, status := CreateBufferCopy(env, uint(len(b)), raw)
+	return res, statusError(env, status)
+}
+
+// stringValue returns the content of a JavaScript String as a Go string.
+func stringValue(env Env, value Value) (string, error) {
+	b, err := stringBytes(env, value)
+	return string(b), err
+}
+
+// stringBytes returns the content of a JavaScript String as UTF-8 bytes.
+func stringBytes(env Env, value Value) ([]byte, error) {
+	var length C.size_t
+	var status = C.napi_get_value_string_utf8(env, value, nil, 0, &length)
+	if err := statusError(env, Status(status)); err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, length+1)
+	status = C.napi_get_value_string_utf8(env, value, (*C.char)(unsafe.Pointer(&buf[0])), length+1, &length)
+	if err := statusError(env, Status(status)); err != nil {
+		return nil, err
+	}
+	return buf[:length], nil
+}
+
+// stringProperty returns the named property of object when it is a String,
+// and the empty string otherwise.
+func stringProperty(env Env, object Value, key string) string {
+	v, status := GetNamedProperty(env, object, key)
+	if status != C.napi_ok {
+		return ""
+	}
+	if t, _ := TypeOf(env, v); t != C.napi_string {
+		return ""
+	}
+	s, _ := stringValue(env, v)
+	return s
+}
+
+func setString(env Env, object Value, key string, value string) error {
+	v, err := stringToValue(env, value)
+	if err != nil {
+		return err
+	}
+	return statusError(env, SetNamedProperty(env, object, key, v))
+}
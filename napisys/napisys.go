@@ -12,6 +12,7 @@ package napisys
 import "C"
 import (
 	"bytes"
+	"runtime/cgo"
 	"unsafe"
 )
 
@@ -123,6 +124,75 @@ var PropertyAttributes = &propertyAttributes{
 	Static:       C.napi_static,
 }
 
+// This is a struct used as container for the modes of GetAllPropertyNames.
+type keyCollectionModes struct {
+	IncludePrototypes int
+	OwnOnly           int
+}
+
+// KeyCollectionModes contains the modes of GetAllPropertyNames:
+// - IncludePrototypes - Used to include the properties of the prototype chain
+// of the object.
+// - OwnOnly - Used to only include the own properties of the object.
+var KeyCollectionModes = &keyCollectionModes{
+	IncludePrototypes: C.napi_key_include_prototypes,
+	OwnOnly:           C.napi_key_own_only,
+}
+
+// KeyCollectionMode tells GetAllPropertyNames whether to include the
+// properties of the prototype chain.
+type KeyCollectionMode = C.napi_key_collection_mode
+
+// This is a struct used as container for the filters of GetAllPropertyNames.
+type keyFilters struct {
+	AllProperties int
+	Writable      int
+	Enumerable    int
+	Configurable  int
+	SkipStrings   int
+	SkipSymbols   int
+}
+
+// KeyFilters contains the bitflags selecting the properties returned by
+// GetAllPropertyNames:
+// - AllProperties - Used to include all the properties.
+// - Writable - Used to only include writable properties.
+// - Enumerable - Used to only include enumerable properties.
+// - Configurable - Used to only include configurable properties.
+// - SkipStrings - Used to skip the properties whose key is a string.
+// - SkipSymbols - Used to skip the properties whose key is a symbol.
+var KeyFilters = &keyFilters{
+	AllProperties: C.napi_key_all_properties,
+	Writable:      C.napi_key_writable,
+	Enumerable:    C.napi_key_enumerable,
+	Configurable:  C.napi_key_configurable,
+	SkipStrings:   C.napi_key_skip_strings,
+	SkipSymbols:   C.napi_key_skip_symbols,
+}
+
+// KeyFilter is a combination of KeyFilters.
+type KeyFilter = C.napi_key_filter
+
+// This is a struct used as container for the key conversions of
+// GetAllPropertyNames.
+type keyConversions struct {
+	KeepNumbers      int
+	NumbersToStrings int
+}
+
+// KeyConversions contains the conversions GetAllPropertyNames applies to the
+// keys of indexed properties:
+// - KeepNumbers - Used to return such keys as Numbers.
+// - NumbersToStrings - Used to return such keys as Strings.
+var KeyConversions = &keyConversions{
+	KeepNumbers:      C.napi_key_keep_numbers,
+	NumbersToStrings: C.napi_key_numbers_to_strings,
+}
+
+// KeyConversion tells GetAllPropertyNames how to return the keys of indexed
+// properties.
+type KeyConversion = C.napi_key_conversion
+
 // ValueType describes the type of NapiValue. This generally corresponds to
 // the types described in Section 6.1 of the ECMAScript Language Specification.
 // In addition to types in that section, NapiValueType can also represent
@@ -1026,6 +1096,53 @@ func CreateSymbol(env Env, value Value) (Value, Status) {
 	return Value(res), Status(status)
 }
 
+// SymbolFor function searches in the global registry for an existing symbol
+// with the given description. If the symbol already exists it will be
+// returned, otherwise a new symbol will be created in the registry.
+// This is the equivalent of Symbol.for(key) in JavaScript.
+// [in] env: The environment that the API is invoked under.
+// [in] key: The description of the symbol, used as its key in the registry.
+// [out] result: A napi_value representing a JavaScript Symbol.
+// Returns napi_ok if the API succeeded.
+// Runtimes older than N-API version 9, and failures of node_api_symbol_for,
+// are served by calling Symbol.for.
+// N-API version: 9
+func SymbolFor(env Env, key string) (Value, Status) {
+	if version, _ := GetVersion(env); version >= 9 {
+		var res C.napi_value
+		var str *C.char
+		if len(key) > 0 {
+			str = (*C.char)(unsafe.Pointer(unsafe.StringData(key)))
+		}
+		var status = C.node_api_symbol_for(env, str, C.size_t(len(key)), &res)
+		if status == C.napi_ok {
+			return Value(res), Status(status)
+		}
+	}
+	return symbolFor(env, key)
+}
+
+// symbolFor calls Symbol.for(key) of the global object.
+func symbolFor(env Env, key string) (Value, Status) {
+	global, status := GetGlobal(env)
+	if status != C.napi_ok {
+		return nil, status
+	}
+	symbol, status := GetNamedProperty(env, global, "Symbol")
+	if status != C.napi_ok {
+		return nil, status
+	}
+	fn, status := GetNamedProperty(env, symbol, "for")
+	if status != C.napi_ok {
+		return nil, status
+	}
+	str, status := CreateStringUtf8(env, key)
+	if status != C.napi_ok {
+		return nil, status
+	}
+	return CallFunction(env, symbol, fn, []Value{str})
+}
+
 // CreateTypedArray function JavaScript TypedArray object over an existing
 // ArrayBuffer.  TypedArray objects provide an array-like view over an underlying
 // data buffer where each element has the same underlying binary scalar datatype.
@@ -1218,11 +1335,28 @@ func GetArrayLength(env Env, value Value) (uint32, Status) {
 	return uint32(res), Status(status)
 }
 
-// GetArrayBufferInfo function the underlying data buffer of a node::Buffer
-// and it's length.
+// GetArrayBufferInfo function returns the underlying data buffer of an
+// ArrayBuffer and its length.
 // [in] env: The environment that the API is invoked under.
-//  N-API version: 1
+// [in] value: napi_value representing the ArrayBuffer being queried.
+// [out] data: The underlying data buffer of the ArrayBuffer.
+// [out] byte_length: Length in bytes of the underlying data buffer.
+// N-API version: 1
 func GetArrayBufferInfo(env Env, value Value) (unsafe.Pointer, uint, Status) {
+	var data unsafe.Pointer
+	var length C.size_t
+	var status = C.napi_get_arraybuffer_info(env, value, &data, &length)
+	return data, uint(length), Status(status)
+}
+
+// GetBufferInfo function returns the underlying data buffer of a node::Buffer
+// and its length.
+// [in] env: The environment that the API is invoked under.
+// [in] value: napi_value representing the node::Buffer being queried.
+// [out] data: The underlying data buffer of the node::Buffer.
+// [out] length: Length in bytes of the underlying data buffer.
+// N-API version: 1
+func GetBufferInfo(env Env, value Value) (unsafe.Pointer, uint, Status) {
 	var data unsafe.Pointer
 	var length C.size_t
 	var status = C.napi_get_buffer_info(env, value, &data, &length)
@@ -1274,18 +1408,19 @@ func GetTypedArrayInfo(env Env, value Value) (Value, TypedArrayType, uint, unsaf
 // [in] env: The environment that the API is invoked under.
 // [in] dataview: napi_value representing the DataView whose properties to query.
 // [out] byte_length: Number of bytes in the DataView.
-// [out] data: The data buffer underlying the DataView.
+// [out] data: The data buffer underlying the DataView, already advanced by
+// byte_offset.
 // [out] arraybuffer: ArrayBuffer underlying the DataView.
 // [out] byte_offset: The byte offset within the data buffer from which to start
 // projecting the DataView.
 // N-API version: 1
-func GetDataviewInfo(env Env, value Value) (Value, uint, uint, Status) {
+func GetDataviewInfo(env Env, value Value) (Value, uint, unsafe.Pointer, uint, Status) {
 	var length C.size_t
 	var data unsafe.Pointer
 	var arraybuffer C.napi_value
 	var offset C.size_t
 	var status = C.napi_get_dataview_info(env, value, &length, &data, &arraybuffer, &offset)
-	return Value(arraybuffer), uint(length), uint(offset), Status(status)
+	return Value(arraybuffer), uint(length), data, uint(offset), Status(status)
 }
 
 // GetValueBool function returns the C boolean primitive equivalent of the
@@ -1676,10 +1811,27 @@ func GetPropertyNames(env Env, object Value) (Value, Status) {
 	return Value(res), Status(status)
 }
 
+// GetAllPropertyNames function returns an array with the names of the
+// properties of object that match the given filter, including or not the
+// properties of its prototype chain.
+// [in] env: The environment that the N-API call is invoked under.
+// [in] object: The object from which to retrieve the properties.
+// [in] mode: Whether to retrieve prototype properties as well (see
+// KeyCollectionModes).
+// [in] filter: Which properties to retrieve, a combination of KeyFilters.
+// [in] conversion: Whether to convert numbered property keys to strings (see
+// KeyConversions).
+// N-API version: 6
+func GetAllPropertyNames(env Env, object Value, mode KeyCollectionMode, filter KeyFilter, conversion KeyConversion) (Value, Status) {
+	var res C.napi_value
+	var status = C.napi_get_all_property_names(env, object, mode, filter, conversion, &res)
+	return Value(res), Status(status)
+}
+
 // SetProperty function set a property on the Object passed in.
 // [in] env: The environment that the N-API call is invoked under.
 // [in] object: The object on which to set the property.
-// [in] key: The name of the property to set.
+// [in] key: The name of the property to set, a String or a Symbol.
 // [in] value: The property value.
 // N-API version: 1
 func SetProperty(env Env, object Value, key Value, value Value) Status {
@@ -1691,7 +1843,7 @@ func SetProperty(env Env, object Value, key Value, value Value) Status {
 // passed in.
 // [in] env: The environment that the N-API call is invoked under.
 // [in] object: The object from which to retrieve the property.
-// [in] key: The name of the property to retrieve.
+// [in] key: The name of the property to retrieve, a String or a Symbol.
 // N-API version: 1
 func GetProperty(env Env, object Value, key Value) (Value, Status) {
 	var res C.napi_value
@@ -1835,14 +1987,86 @@ func DeleteElement(env Env, object Value, index uint) (bool, Status) {
 func DefineProperties(env Env, value Value, properties []Property) Status {
 	raw := make([]PropertyDescriptor, len(properties))
 	for i := range properties {
-		prop := properties[i].getRaw()
+		prop, status := properties[i].getRaw(env)
+		if prop.utf8name != nil {
+			defer C.free(unsafe.Pointer(prop.utf8name))
+		}
+		if status != C.napi_ok {
+			return status
+		}
 		raw[i] = prop
 	}
-	var props = (unsafe.Pointer(&raw[0]))
-	var status = C.napi_define_properties(env, value, C.size_t(len(properties)), (*C.napi_property_descriptor)(props))
+	var props = (*C.napi_property_descriptor)(nil)
+	if len(raw) > 0 {
+		props = &raw[0]
+	}
+	var status = C.napi_define_properties(env, value, C.size_t(len(properties)), props)
+	return Status(status)
+}
+
+// ObjectFreeze function freezes a given object. This prevents new properties
+// from being added to it, existing properties from being removed, prevents
+// changing the enumerability, configurability, or writability of existing
+// properties, and prevents the values of existing properties from being
+// changed. It also prevents the object's prototype from being changed. This is
+// described in Section 19.1.2.6 of the ECMA-262 specification.
+// [in] env: The environment that the N-API call is invoked under.
+// [in] object: The object to freeze.
+// N-API version: 8
+func ObjectFreeze(env Env, object Value) Status {
+	var status = C.napi_object_freeze(env, object)
 	return Status(status)
 }
 
+// ObjectSeal function seals a given object. This prevents new properties from
+// being added to it, as well as marking all existing properties as
+// non-configurable. This is described in Section 19.1.2.20 of the ECMA-262
+// specification.
+// [in] env: The environment that the N-API call is invoked under.
+// [in] object: The object to seal.
+// N-API version: 8
+func ObjectSeal(env Env, object Value) Status {
+	var status = C.napi_object_seal(env, object)
+	return Status(status)
+}
+
+// TypeTag is a 128-bit value identifying the native type of an object, used
+// with TypeTagObject and CheckObjectTypeTag.
+type TypeTag = C.napi_type_tag
+
+// NewTypeTag function creates a TypeTag from its lower and upper 64 bits.
+func NewTypeTag(lower uint64, upper uint64) TypeTag {
+	return TypeTag{lower: C.uint64_t(lower), upper: C.uint64_t(upper)}
+}
+
+// TypeTagObject function associates the value of the tag pointer with the
+// JavaScript object or external. CheckObjectTypeTag can then be used to
+// compare the tag that was attached to the object with one owned by the addon
+// to ensure that the object has the right type.
+// If the object already has an associated type tag, this API will return
+// napi_invalid_arg.
+// [in] env: The environment that the N-API call is invoked under.
+// [in] value: The JavaScript object or external to be marked.
+// [in] tag: The tag with which the object is to be marked.
+// N-API version: 8
+func TypeTagObject(env Env, value Value, tag TypeTag) Status {
+	var status = C.napi_type_tag_object(env, value, &tag)
+	return Status(status)
+}
+
+// CheckObjectTypeTag function compares the tag given with the one found on
+// the JavaScript object or external. If no tag is found on the object, or if
+// the tags differ, the result is false.
+// [in] env: The environment that the N-API call is invoked under.
+// [in] value: The JavaScript object or external whose type tag to examine.
+// [in] tag: The tag with which to compare any tag found on the object.
+// N-API version: 8
+func CheckObjectTypeTag(env Env, value Value, tag TypeTag) (bool, Status) {
+	var res C.bool
+	var status = C.napi_check_object_type_tag(env, value, &tag, &res)
+	return bool(res), Status(status)
+}
+
 // Working with JavaScript Functions
 // N-API provides a set of APIs that allow JavaScript code to call back into
 // native code.  N-API APIs that support calling back into native code take in a
@@ -1872,8 +2096,7 @@ func DefineProperties(env Env, value Value, properties []Property) Status {
 // N-API version: 1
 func CallFunction(env Env, receiver Value, function Value, arguments []Value) (Value, Status) {
 	var res C.napi_value
-	var args = unsafe.Pointer(&arguments[0])
-	var status = C.napi_call_function(env, receiver, function, C.size_t(len(arguments)), (*C.napi_value)(args), &res)
+	var status = C.napi_call_function(env, receiver, function, C.size_t(len(arguments)), valuesPtr(arguments), &res)
 	return Value(res), Status(status)
 }
 
@@ -1897,7 +2120,14 @@ func CreateFunction(env Env, name string, cb CCallback) (Value, Status) {
 	var res C.napi_value
 	var cname = C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
-	var status = C.napi_create_function(env, cname, C.NAPI_AUTO_LENGTH, (Callback)(C.Callback(unsafe.Pointer(caller))), nil, &res)
+	// The caller is reached through a handle passed as the data of the
+	// function, which also keeps it alive for the Go garbage collector until
+	// the function itself is collected.
+	var handle = cgo.NewHandle(caller)
+	var status = C.CreateGoFunction(env, cname, C.uintptr_t(handle), &res)
+	if status != C.napi_ok && res == nil {
+		handle.Delete()
+	}
 	return Value(res), Status(status)
 }
 
@@ -1911,27 +2141,12 @@ func CreateFunction(env Env, name string, cb CCallback) (Value, Status) {
 // [out] argv: Buffer to which the napi_value representing the arguments are copied. If there are more arguments than the provided count, only the requested number of arguments are copied. If there are fewer arguments provided than claimed, the rest of argv is filled with napi_value values that represent undefined.
 // [out] this: Receives the JavaScript this argument for the call.
 // [out] data: Receives the data pointer for the callback.
+// GetArgs gives typed access to the same details without allocating for calls
+// with few arguments.
 // N-API version: 1
 func GetCbInfo(env Env, cbinfo CallbackInfo) ([]Value, Value, unsafe.Pointer, Status) {
-	var staticArgc C.size_t = 5
-	var argc = staticArgc
-	staticArgv := make([]Value, 5)
-	var dynamicArgv []Value
-	var cStaticArgv = (unsafe.Pointer(&staticArgv[0]))
-	var thisArg C.napi_value
-	var data unsafe.Pointer
-	var status = C.napi_get_cb_info(env, cbinfo, &argc, (*C.napi_value)(cStaticArgv), &thisArg, &data)
-	if argc > staticArgc {
-		dynamicArgv = make([]Value, int(argc))
-		var cDynamicArgv = (unsafe.Pointer(&dynamicArgv[0]))
-		var status = C.napi_get_cb_info(env, cbinfo, &argc, (*C.napi_value)(cDynamicArgv), nil, nil)
-		return dynamicArgv, Value(thisArg), data, Status(status)
-	}
-	arguments := make([]Value, int(argc))
-	for i := 0; i < int(argc); i++ {
-		arguments[i] = staticArgv[i]
-	}
-	return arguments, Value(thisArg), data, Status(status)
+	args, status := GetArgs(env, cbinfo)
+	return args.Values(), args.This(), args.Data(), status
 }
 
 // GetNewTarget function returns the new.target of the constructor call. If
@@ -1958,9 +2173,7 @@ func GetNewTarget(env Env, cbinfo CallbackInfo) (Value, Status) {
 // N-API version: 1
 func NewInstance(env Env, ctor Value, arguments []Value) (Value, Status) {
 	var res C.napi_value
-	var args = unsafe.Pointer(&arguments[0])
-	// defer C.free(args)
-	var status = C.napi_new_instance(env, ctor, C.size_t(len(arguments)), (*C.napi_value)(args), &res)
+	var status = C.napi_new_instance(env, ctor, C.size_t(len(arguments)), valuesPtr(arguments), &res)
 	return Value(res), Status(status)
 }
 
@@ -2161,7 +2374,8 @@ func AddFinalizer(env Env, obj Value, native unsafe.Pointer, finalizer *Finalize
 // diagnostic information exposed by the async hooks API.
 // [in] execute: The native function which should be called to execute the logic
 // asynchronously. The given function is called from a worker pool thread and can
-// execute in parallel with the main event loop thread.
+// execute in parallel with the main event loop thread. After UseAsyncPool it is
+// called from a goroutine of the add-on pool instead.
 // [in] complete: The native function which will be called when the asynchronous
 // logic is completed or is cancelled. The given function is called from the main
 // event loop thread.
@@ -2170,6 +2384,9 @@ func AddFinalizer(env Env, obj Value, native unsafe.Pointer, finalizer *Finalize
 // [out] result: Returns the handle to the newly created async work.
 // N-API version: 1
 func CreateAsyncWork(env Env, resource Value, name Value, execute *AsyncExecuteCaller, complete *AsyncCompleteCaller, data unsafe.Pointer) (AsyncWork, Status) {
+	if pool.isEnabled() {
+		return createPoolWork(env, execute, complete, data)
+	}
 	var res C.napi_async_work
 	cexecute := C.AsyncExecuteCallback(unsafe.Pointer(execute))
 	ccomplete := C.AsyncCompleteCallback(unsafe.Pointer(complete))
@@ -2184,6 +2401,9 @@ func CreateAsyncWork(env Env, resource Value, name Value, execute *AsyncExecuteC
 // [in] work: The handle returned by the call to CreateAsyncWork.
 // N-API version: 1
 func DeleteAsyncWork(env Env, work AsyncWork) Status {
+	if w := lookupPoolWork(work); w != nil {
+		return w.delete()
+	}
 	var status = C.napi_delete_async_work(env, work)
 	return Status(status)
 }
@@ -2196,6 +2416,9 @@ func DeleteAsyncWork(env Env, work AsyncWork) Status {
 // [in] work: The handle returned by the call to CreateAsyncWork function.s
 // N-API version: 1
 func QueueAsyncWork(env Env, work AsyncWork) Status {
+	if w := lookupPoolWork(work); w != nil {
+		return w.queue()
+	}
 	var status = C.napi_queue_async_work(env, work)
 	return Status(status)
 }
@@ -2210,6 +2433,9 @@ func QueueAsyncWork(env Env, work AsyncWork) Status {
 // [in] work: The handle returned by the call to CreateAsyncWork.
 // N-API version: 1
 func CancelAsyncWork(env Env, work AsyncWork) Status {
+	if w := lookupPoolWork(work); w != nil {
+		return w.cancel()
+	}
 	var status = C.napi_cancel_async_work(env, work)
 	return Status(status)
 }
@@ -2260,7 +2486,7 @@ func AsyncDestroy(env Env, ctx AsyncContext) Status {
 // N-API version: 1
 func MakeCallback(env Env, ctx AsyncContext, recv Value, fn Value, args []Value) (Value, Status) {
 	var res C.napi_value
-	var argv = (*C.napi_value)(unsafe.Pointer(&args[0]))
+	var argv = valuesPtr(args)
 	var argc = C.size_t(len(args))
 	var status = C.napi_make_callback(env, ctx, recv, fn, argc, argv, &res)
 	return Value(res), Status(status)
@@ -2419,6 +2645,19 @@ func GetUvEventLoop(env Env) (UVLoop, Status) {
 	return loop, Status(status)
 }
 
+// GetModuleFileName function returns the absolute path of the location from
+// which the add-on was loaded, as a file:// URL.
+// [in] env: The environment that the API is invoked under.
+// N-API version: 9
+func GetModuleFileName(env Env) (string, Status) {
+	var res *C.char
+	var status = C.node_api_get_module_file_name(env, &res)
+	if status != C.napi_ok {
+		return "", Status(status)
+	}
+	return C.GoString(res), Status(status)
+}
+
 // Asynchronous Thread-safe Function Calls
 // JavaScript functions can normally only be called from a native addon's main
 // thread. If an addon creates additional threads, then N-API functions that
@@ -2548,6 +2787,25 @@ func CallCallback(wrap unsafe.Pointer, env C.napi_env, info C.napi_callback_info
 	return (C.napi_value)(caller.Cb(Env(env), CallbackInfo(info)))
 }
 
+//export CallGoFunction
+func CallGoFunction(env C.napi_env, info C.napi_callback_info, handle C.uintptr_t) C.napi_value {
+	caller := cgo.Handle(handle).Value().(*Caller)
+	return (C.napi_value)(caller.Cb(Env(env), CallbackInfo(info)))
+}
+
+//export FinalizeGoFunction
+func FinalizeGoFunction(handle C.uintptr_t) {
+	cgo.Handle(handle).Delete()
+}
+
+//export RunGoFinalizer
+func RunGoFinalizer(env C.napi_env, handle C.uintptr_t) {
+	h := cgo.Handle(handle)
+	fn := h.Value().(func(Env))
+	h.Delete()
+	fn(Env(env))
+}
+
 // CAsyncExecuteCallback  ...
 type CAsyncExecuteCallback func(Env, unsafe.Pointer)
 
@@ -2605,24 +2863,38 @@ func CallThreadsafeFunctionCallback(wrap unsafe.Pointer, env C.napi_env, fn C.na
 }
 
 // Property ...
+// The key of the property is Symbol when it is set, for example to a
+// well-known symbol or to one returned by SymbolFor, and Name otherwise.
 type Property struct {
 	Name   string
+	Symbol Value
 	Method *Caller
 }
 
 // GetRaw ...
-func (prop *Property) getRaw() PropertyDescriptor {
-	name := C.CString(prop.Name)
-	defer C.free(unsafe.Pointer(name))
+// The method, if any, is created as a function of its own whose Caller is
+// released when the function is collected, and set as the value of the
+// property. The name of the returned descriptor, if any, must be freed by the
+// caller.
+func (prop *Property) getRaw(env Env) (PropertyDescriptor, Status) {
+	var name *C.char
+	if prop.Symbol == nil {
+		name = C.CString(prop.Name)
+	}
 	desc := PropertyDescriptor{
 		utf8name:   name,
-		name:       nil,
-		method:     (Callback)(C.Callback(unsafe.Pointer(prop.Method))), //nil,
+		name:       prop.Symbol,
+		method:     nil,
 		getter:     nil,
 		setter:     nil,
 		value:      nil,
 		attributes: C.napi_default,
 		data:       nil,
 	}
-	return desc
+	if prop.Method == nil {
+		return desc, C.napi_ok
+	}
+	method, status := CreateFunction(env, prop.Name, prop.Method.Cb)
+	desc.value = method
+	return desc, status
 }
@@ -0,0 +1,136 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+// Promises
+// A Deferred returned by CreatePromise must be settled exactly once, on the
+// main thread. Promise wraps it so that it can be settled from any goroutine:
+// Resolve and Reject hand the outcome to the executor of the environment, and
+// only the first of them has an effect. A Promise that is still pending keeps
+// the event loop alive. It is rejected automatically when the environment
+// shuts down, or when the Go value is garbage collected before being settled,
+// so that no JavaScript caller waits forever on a goroutine that gave up.
+
+// ErrAlreadySettled is returned by the methods of Promise once the promise has
+// been resolved or rejected.
+var ErrAlreadySettled = errors.New("napisys: promise already settled")
+
+// ErrPromiseAbandoned is the reason of a Promise rejected because the Go value
+// was garbage collected before being settled.
+var ErrPromiseAbandoned = errors.New("napisys: promise abandoned without being settled")
+
+// Promise is a JavaScript promise that can be settled from any goroutine.
+type Promise struct {
+	state *promiseState
+}
+
+// promiseState is kept apart from Promise so that the finalizer of the latter
+// can run while the executor still references the state.
+type promiseState struct {
+	env        Env
+	ex         *executor
+	deferred   Deferred
+	settled    atomic.Bool
+	removeHook func()
+}
+
+// NewPromise function creates a JavaScript promise along with the Promise used
+// to settle it. It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+func NewPromise(env Env) (Value, *Promise, error) {
+	ex, err := executorFor(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, deferred, status := CreatePromise(env)
+	if err := statusError(env, status); err != nil {
+		return nil, nil, err
+	}
+	s := &promiseState{env: env, ex: ex, deferred: deferred}
+	s.removeHook = ex.addCloseHook(func(env Env) {
+		if s.settled.CompareAndSwap(false, true) {
+			s.complete(env, false, ErrEnvClosed)
+		}
+	})
+	ex.ref(env)
+	p := &Promise{state: s}
+	runtime.SetFinalizer(p, func(p *Promise) {
+		p.state.settle(false, ErrPromiseAbandoned)
+	})
+	return value, p, nil
+}
+
+// Resolve resolves the promise with v, converted with ToValue on the main
+// thread. If the conversion fails the promise is rejected with its error.
+// It may be called from any goroutine.
+// Returns ErrAlreadySettled if the promise was already settled.
+func (p *Promise) Resolve(v interface{}) error {
+	return p.state.settle(true, v)
+}
+
+// Reject rejects the promise with err, converted with ToValue on the main
+// thread. A nil err rejects the promise with undefined.
+// It may be called from any goroutine.
+// Returns ErrAlreadySettled if the promise was already settled.
+func (p *Promise) Reject(err error) error {
+	return p.state.settle(false, err)
+}
+
+// Settle rejects the promise with err when it is not nil and resolves it with
+// v otherwise, matching the usual (value, error) results of Go functions.
+func (p *Promise) Settle(v interface{}, err error) error {
+	if err != nil {
+		return p.Reject(err)
+	}
+	return p.Resolve(v)
+}
+
+// Settled reports whether Resolve or Reject was already called.
+func (p *Promise) Settled() bool {
+	return p.state.settled.Load()
+}
+
+func (s *promiseState) settle(resolve bool, v interface{}) error {
+	if !s.settled.CompareAndSwap(false, true) {
+		return ErrAlreadySettled
+	}
+	task := &executorTask{run: func(env Env) error {
+		s.complete(env, resolve, v)
+		return nil
+	}}
+	if s.ex.onMainThread() {
+		s.ex.run(s.env, task)
+		return nil
+	}
+	return s.ex.post(task)
+}
+
+// complete settles the deferred on the main thread.
+func (s *promiseState) complete(env Env, resolve bool, v interface{}) {
+	s.removeHook()
+	s.ex.unref(env)
+	if resolve {
+		value, err := ToValue(env, v)
+		if err == nil {
+			ResolveDeferred(env, s.deferred, value)
+			return
+		}
+		v = err
+	}
+	var reason Value
+	if err, ok := v.(error); ok && err != nil {
+		reason, _ = errorValue(env, err)
+	}
+	if reason == nil {
+		reason, _ = GetUndefined(env)
+	}
+	RejectDeferred(env, s.deferred, reason)
+}
@@ -0,0 +1,193 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// Readable streams
+// NewReadable exposes an io.Reader to JavaScript as a stream.Readable, so that
+// large Go outputs can be piped instead of being built into a single Buffer.
+// Each time the stream asks for data a goroutine reads one chunk from the
+// reader, and the chunk is pushed on the main thread as a Buffer. The stream
+// only asks for more data while its buffer is below its highWaterMark, which
+// stops reading from Go when the consumer is slow.
+
+// defaultChunkSize is the size of the chunks read when the stream does not
+// give one.
+const defaultChunkSize = 16 * 1024
+
+// ReadableOptions configures a stream created by NewReadable.
+type ReadableOptions struct {
+	// HighWaterMark is the number of bytes the stream buffers before it stops
+	// reading from the io.Reader. Zero means the default of Node.js.
+	HighWaterMark int
+}
+
+// readable is the Go side of a stream created by NewReadable.
+type readable struct {
+	r  io.Reader
+	ex *executor
+
+	mu        sync.Mutex
+	destroyed bool
+}
+
+// NewReadable function creates a stream.Readable yielding the bytes read from
+// r. A read error destroys the stream with that error, which is then emitted
+// as 'error'. When the stream is destroyed, r is closed if it implements
+// io.Closer. It must be called on the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] r: The reader to read the bytes from.
+// [in] opts: The options of the stream.
+func NewReadable(env Env, r io.Reader, opts ReadableOptions) (Value, error) {
+	ex, err := executorFor(env)
+	if err != nil {
+		return nil, err
+	}
+	ctor, err := streamClass(env, "Readable")
+	if err != nil {
+		return nil, err
+	}
+	s := &readable{r: r, ex: ex}
+	read, err := NewFunction(env, "read", s.read)
+	if err != nil {
+		return nil, err
+	}
+	destroy, err := NewFunction(env, "destroy", s.destroy)
+	if err != nil {
+		return nil, err
+	}
+	options := map[string]interface{}{"read": read, "destroy": destroy}
+	if opts.HighWaterMark > 0 {
+		options["highWaterMark"] = opts.HighWaterMark
+	}
+	return New(env, ctor, options)
+}
+
+// read implements _read(size): it reads one chunk on a goroutine and pushes
+// it on the main thread.
+func (s *readable) read(env Env, args *Args) (interface{}, error) {
+	size, ok := args.Int32Or(0, defaultChunkSize)
+	if !ok {
+		return nil, nil
+	}
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	stream, status := CreateReference(env, args.This(), 1)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	s.ex.ref(env)
+	go func() {
+		buf := make([]byte, size)
+		var n int
+		var err error
+		for n == 0 && err == nil {
+			n, err = s.r.Read(buf)
+		}
+		s.ex.post(&executorTask{run: func(env Env) error {
+			defer s.ex.unref(env)
+			defer DeleteReference(env, stream)
+			if s.isDestroyed() {
+				return nil
+			}
+			this, status := GetReferenceValue(env, stream)
+			if err := statusError(env, status); err != nil {
+				return err
+			}
+			if n > 0 {
+				if _, err := CallMethod(env, this, "push", buf[:n]); err != nil {
+					return err
+				}
+			}
+			switch {
+			case err == nil:
+			case errors.Is(err, io.EOF):
+				_, err = CallMethod(env, this, "push", nil)
+				return err
+			default:
+				_, err = CallMethod(env, this, "destroy", err)
+				return err
+			}
+			return nil
+		}})
+	}()
+	return nil, nil
+}
+
+// destroy implements _destroy(err, callback): it closes the reader on a
+// goroutine and calls callback on the main thread once done.
+func (s *readable) destroy(env Env, args *Args) (interface{}, error) {
+	s.mu.Lock()
+	s.destroyed = true
+	s.mu.Unlock()
+	reason := args.At(0)
+	callback, ok := args.Func(1)
+	if !ok {
+		return nil, nil
+	}
+	closer, ok := s.r.(io.Closer)
+	if !ok {
+		_, err := Call(env, callback, nil, reason)
+		return nil, err
+	}
+	callbackRef, status := CreateReference(env, callback, 1)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	// Streams are destroyed with an Error or without a reason, only the former
+	// needs to be kept alive until the callback is called.
+	var reasonRef Ref
+	if t, _ := TypeOf(env, reason); t == C.napi_object || t == C.napi_function {
+		if reasonRef, status = CreateReference(env, reason, 1); status != C.napi_ok {
+			DeleteReference(env, callbackRef)
+			return nil, statusError(env, status)
+		}
+	}
+	s.ex.ref(env)
+	go func() {
+		closeErr := closer.Close()
+		s.ex.post(&executorTask{run: func(env Env) error {
+			defer s.ex.unref(env)
+			defer DeleteReference(env, callbackRef)
+			callback, status := GetReferenceValue(env, callbackRef)
+			if err := statusError(env, status); err != nil {
+				return err
+			}
+			var reason interface{} = closeErr
+			if reasonRef != nil {
+				reason, _ = GetReferenceValue(env, reasonRef)
+				DeleteReference(env, reasonRef)
+			}
+			_, err := Call(env, callback, nil, reason)
+			return err
+		}})
+	}()
+	return nil, nil
+}
+
+func (s *readable) isDestroyed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.destroyed
+}
+
+// streamClass returns a class of the stream module of Node.js.
+func streamClass(env Env, name string) (Value, error) {
+	stream, err := builtinModule(env, "stream")
+	if err != nil {
+		return nil, err
+	}
+	res, status := GetNamedProperty(env, stream, name)
+	if status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return res, nil
+}
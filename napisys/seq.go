@@ -0,0 +1,83 @@
+package napisys
+
+import "iter"
+
+// Go sequences as JavaScript iterators
+// NewIterator and NewIterator2 expose Go sequences to JavaScript as iterators,
+// usable with for...of, spread or Array.from. The sequence is turned into a
+// pull iterator with iter.Pull, so each call to next() runs the sequence until
+// it yields exactly one more element, on the main thread. Elements are
+// converted with ToValue, so a sequence may yield plain Go values as well as
+// Values it creates itself while running. The sequence is stopped when the
+// iteration ends, when return() or throw() is called, or when the iterator is
+// garbage collected.
+
+// NewIterator function creates a JavaScript iterator over the elements of seq.
+// [in] env: The environment that the API is invoked under.
+// [in] seq: The sequence to iterate over.
+func NewIterator[T any](env Env, seq iter.Seq[T]) (Value, error) {
+	next, stop := iter.Pull(seq)
+	return newPullIterator(env, func() (interface{}, bool) {
+		return next()
+	}, stop)
+}
+
+// NewIterator2 function creates a JavaScript iterator over the pairs of seq,
+// each one yielded as a [key, value] Array like the entries of a Map.
+// [in] env: The environment that the API is invoked under.
+// [in] seq: The sequence to iterate over.
+func NewIterator2[K, V any](env Env, seq iter.Seq2[K, V]) (Value, error) {
+	next, stop := iter.Pull2(seq)
+	return newPullIterator(env, func() (interface{}, bool) {
+		k, v, ok := next()
+		return []interface{}{k, v}, ok
+	}, stop)
+}
+
+// newPullIterator creates the iterator object pulling its elements from next.
+func newPullIterator(env Env, next func() (interface{}, bool), stop func()) (Value, error) {
+	finished := false
+	finish := func() {
+		if !finished {
+			finished = true
+			stop()
+		}
+	}
+	res, err := newIteratorObject(env, SymbolIterator, map[string]Func{
+		"next": func(env Env, args *Args) (v interface{}, err error) {
+			if finished {
+				return doneResult, nil
+			}
+			defer func() {
+				if r := recover(); r != nil {
+					finished = true
+					panic(r)
+				}
+			}()
+			v, ok := next()
+			if !ok {
+				finish()
+				return doneResult, nil
+			}
+			return iterResult{Value: v}, nil
+		},
+		"return": func(env Env, args *Args) (interface{}, error) {
+			finish()
+			return iterResult{Value: args.At(0), Done: true}, nil
+		},
+		"throw": func(env Env, args *Args) (interface{}, error) {
+			finish()
+			Throw(env, args.At(0))
+			return nil, nil
+		},
+	})
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	if err := addFinalizer(env, res, func(Env) { finish() }); err != nil {
+		finish()
+		return nil, err
+	}
+	return res, nil
+}
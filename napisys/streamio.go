@@ -0,0 +1,422 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Go readers and writers over JavaScript streams
+// StreamWriter and StreamReader let Go code use Node.js streams through the
+// io.Writer and io.Reader interfaces, typically from a goroutine. Calls made
+// off the main thread are carried to it by the executor of the environment.
+// Backpressure is honoured both ways: Write waits for 'drain' when write()
+// returns false, and the readable is paused while the data StreamReader has
+// buffered but not yet returned from Read exceeds its limit. Both keep the
+// event loop alive until they are closed or the stream ends.
+
+// streamReaderLimit is the number of bytes StreamReader buffers before it
+// pauses the stream.
+const streamReaderLimit = 64 * 1024
+
+// StreamWriter is an io.WriteCloser writing to a JavaScript Writable.
+type StreamWriter struct {
+	env     Env
+	ex      *executor
+	stream  Ref
+	off     func(env Env, stream Value)
+	release func()
+
+	mu       sync.Mutex
+	err      error
+	finished bool
+	closed   chan struct{}
+	once     sync.Once
+	doneOnce sync.Once
+}
+
+// NewStreamWriter function creates an io.WriteCloser writing to a JavaScript
+// Writable stream. An 'error' emitted by the stream is returned by the next
+// call to Write or Close instead of being thrown. It must be called on the
+// main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] stream: The Writable stream to write to.
+func NewStreamWriter(env Env, stream Value) (*StreamWriter, error) {
+	ex, err := executorFor(env)
+	if err != nil {
+		return nil, err
+	}
+	w := &StreamWriter{env: env, ex: ex, closed: make(chan struct{})}
+	w.off, err = onStreamEvents(env, stream, map[string]Func{
+		"error": func(env Env, args *Args) (interface{}, error) {
+			w.fail(exceptionError(env, args.At(0)))
+			return nil, nil
+		},
+		"close": func(env Env, args *Args) (interface{}, error) {
+			w.fail(io.ErrClosedPipe)
+			return nil, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ref, status := CreateReference(env, stream, 1)
+	if err := statusError(env, status); err != nil {
+		w.off(env, stream)
+		return nil, err
+	}
+	w.stream = ref
+	if w.release, err = KeepAlive(env); err != nil {
+		w.off(env, stream)
+		DeleteReference(env, ref)
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes p as a Buffer with write(). When write() returns false, Write
+// waits for 'drain' before returning, unless it is called on the main thread,
+// where waiting is not possible.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	if err := w.error(); err != nil {
+		return 0, err
+	}
+	chunk := append([]byte(nil), p...)
+	wait := !w.ex.onMainThread()
+	drain, err := Invoke(context.Background(), w.env, func(env Env) (chan struct{}, error) {
+		stream, err := w.value(env)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := CallMethod(env, stream, "write", chunk)
+		if err != nil {
+			return nil, err
+		}
+		if b, _ := GetValueBool(env, ok); b || !wait {
+			return nil, nil
+		}
+		drain := make(chan struct{})
+		return drain, onceStreamEvent(env, stream, "drain", func() { close(drain) })
+	})
+	if err != nil {
+		return 0, err
+	}
+	if drain != nil {
+		select {
+		case <-drain:
+		case <-w.closed:
+			return len(p), w.error()
+		}
+	}
+	return len(p), nil
+}
+
+// Close ends the stream with end() and waits for 'finish'. It returns
+// io.ErrClosedPipe when the stream was closed before emitting 'finish', in
+// which case some of the data written may have been lost. It must not be
+// called on the main thread.
+func (w *StreamWriter) Close() error {
+	if err := w.error(); err != nil {
+		w.done()
+		if err == io.ErrClosedPipe && w.hasFinished() {
+			return nil
+		}
+		return err
+	}
+	finish, err := Invoke(context.Background(), w.env, func(env Env) (chan struct{}, error) {
+		stream, err := w.value(env)
+		if err != nil {
+			return nil, err
+		}
+		finish := make(chan struct{})
+		if err := onceStreamEvent(env, stream, "finish", func() {
+			w.mu.Lock()
+			w.finished = true
+			w.mu.Unlock()
+			close(finish)
+		}); err != nil {
+			return nil, err
+		}
+		_, err = CallMethod(env, stream, "end")
+		return finish, err
+	})
+	if err == nil {
+		select {
+		case <-finish:
+		case <-w.closed:
+			if err = w.error(); err == io.ErrClosedPipe && w.hasFinished() {
+				err = nil
+			}
+		}
+	}
+	w.done()
+	return err
+}
+
+// hasFinished reports whether the stream emitted 'finish' after Close ended
+// it.
+func (w *StreamWriter) hasFinished() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.finished
+}
+
+func (w *StreamWriter) value(env Env) (Value, error) {
+	res, status := GetReferenceValue(env, w.stream)
+	return res, statusError(env, status)
+}
+
+func (w *StreamWriter) fail(err error) {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+	w.once.Do(func() { close(w.closed) })
+	w.done()
+}
+
+func (w *StreamWriter) error() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// done removes the listeners of the writer, releases the stream and lets the
+// event loop exit, once the stream closed or the writer was closed.
+func (w *StreamWriter) done() {
+	w.doneOnce.Do(func() {
+		w.ex.post(&executorTask{run: func(env Env) error {
+			if stream, status := GetReferenceValue(env, w.stream); status == C.napi_ok {
+				w.off(env, stream)
+			}
+			return statusError(env, DeleteReference(env, w.stream))
+		}})
+		w.release()
+	})
+}
+
+// StreamReader is an io.ReadCloser reading from a JavaScript Readable.
+type StreamReader struct {
+	env     Env
+	stream  Ref
+	release func()
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	chunks [][]byte
+	size   int
+	paused bool
+	err    error
+}
+
+// NewStreamReader function creates an io.ReadCloser reading the data emitted
+// by a JavaScript Readable stream. Read returns io.EOF after 'end' and the
+// error of 'error'. Strings emitted by streams with an encoding are read as
+// their UTF-8 bytes. Any other chunk, as emitted by streams in object mode,
+// destroys the stream and makes Read return an error. It must be called on
+// the main thread.
+// [in] env: The environment that the API is invoked under.
+// [in] stream: The Readable stream to read from.
+func NewStreamReader(env Env, stream Value) (*StreamReader, error) {
+	ref, status := CreateReference(env, stream, 1)
+	if err := statusError(env, status); err != nil {
+		return nil, err
+	}
+	r := &StreamReader{env: env, stream: ref}
+	r.cond = sync.NewCond(&r.mu)
+	release, err := KeepAlive(env)
+	if err != nil {
+		DeleteReference(env, ref)
+		return nil, err
+	}
+	var once sync.Once
+	off := func(env Env, stream Value) {}
+	r.release = func() {
+		once.Do(func() {
+			Post(env, func(env Env) {
+				if stream, status := GetReferenceValue(env, ref); status == C.napi_ok {
+					off(env, stream)
+				}
+				DeleteReference(env, ref)
+			})
+			release()
+		})
+	}
+	added, err := onStreamEvents(env, stream, map[string]Func{
+		"data": func(env Env, args *Args) (interface{}, error) {
+			chunk, err := FromValue(env, args.At(0))
+			if s, ok := chunk.(string); ok {
+				chunk = []byte(s)
+			}
+			if b, ok := chunk.([]byte); ok {
+				if r.push(b) {
+					_, err := CallMethod(env, args.This(), "pause")
+					return nil, err
+				}
+				return nil, nil
+			}
+			if err == nil {
+				err = fmt.Errorf("napisys: cannot read a %T chunk from a stream: only Buffers and strings can be read", chunk)
+			}
+			r.finish(err)
+			_, err = CallMethod(env, args.This(), "destroy")
+			return nil, err
+		},
+		"end": func(env Env, args *Args) (interface{}, error) {
+			r.finish(io.EOF)
+			return nil, nil
+		},
+		"error": func(env Env, args *Args) (interface{}, error) {
+			r.finish(exceptionError(env, args.At(0)))
+			return nil, nil
+		},
+		"close": func(env Env, args *Args) (interface{}, error) {
+			r.finish(io.ErrUnexpectedEOF)
+			return nil, nil
+		},
+	})
+	if err != nil {
+		r.release()
+		return nil, err
+	}
+	off = added
+	return r, nil
+}
+
+// Read reads buffered data, waiting for the stream to emit more when none is
+// buffered. It must not be called on the main thread.
+func (r *StreamReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.chunks) == 0 && r.err == nil {
+		r.cond.Wait()
+	}
+	if len(r.chunks) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.chunks[0])
+	if n == len(r.chunks[0]) {
+		r.chunks[0] = nil
+		r.chunks = r.chunks[1:]
+	} else {
+		r.chunks[0] = r.chunks[0][n:]
+	}
+	r.size -= n
+	if r.paused && r.size < streamReaderLimit && r.err == nil {
+		r.paused = false
+		Post(r.env, func(env Env) {
+			if stream, status := GetReferenceValue(env, r.stream); status == C.napi_ok {
+				CallMethod(env, stream, "resume")
+			}
+		})
+	}
+	return n, nil
+}
+
+// Close stops reading and destroys the stream. It may be called from any
+// goroutine.
+func (r *StreamReader) Close() error {
+	r.mu.Lock()
+	done := r.err != nil
+	r.err = io.ErrClosedPipe
+	r.chunks, r.size = nil, 0
+	r.mu.Unlock()
+	r.cond.Broadcast()
+	if !done {
+		Post(r.env, func(env Env) {
+			if stream, status := GetReferenceValue(env, r.stream); status == C.napi_ok {
+				CallMethod(env, stream, "destroy")
+			}
+		})
+	}
+	r.release()
+	return nil
+}
+
+// push buffers a chunk, reporting whether the stream must be paused.
+func (r *StreamReader) push(chunk []byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return false
+	}
+	r.chunks = append(r.chunks, chunk)
+	r.size += len(chunk)
+	r.cond.Broadcast()
+	if r.size >= streamReaderLimit && !r.paused {
+		r.paused = true
+		return true
+	}
+	return false
+}
+
+// finish records the end of the stream, keeping the first reason.
+func (r *StreamReader) finish(err error) {
+	r.mu.Lock()
+	ended := r.err != nil
+	if !ended {
+		r.err = err
+	}
+	r.mu.Unlock()
+	r.cond.Broadcast()
+	if !ended {
+		r.release()
+	}
+}
+
+// onStreamEvents adds a listener implemented by a Go function for each of the
+// given events. It returns a function removing them from the stream, which
+// must be called on the main thread once they are no longer needed.
+func onStreamEvents(env Env, stream Value, listeners map[string]Func) (func(env Env, stream Value), error) {
+	refs := make(map[string]Ref, len(listeners))
+	off := func(env Env, stream Value) {
+		for event, ref := range refs {
+			if listener, status := GetReferenceValue(env, ref); status == C.napi_ok {
+				CallMethod(env, stream, "removeListener", event, listener)
+			}
+			DeleteReference(env, ref)
+		}
+		refs = nil
+	}
+	for event, fn := range listeners {
+		listener, err := NewFunction(env, event, fn)
+		if err != nil {
+			off(env, stream)
+			return nil, err
+		}
+		if _, err := CallMethod(env, stream, "on", event, listener); err != nil {
+			off(env, stream)
+			return nil, err
+		}
+		ref, status := CreateReference(env, listener, 1)
+		if err := statusError(env, status); err != nil {
+			CallMethod(env, stream, "removeListener", event, listener)
+			off(env, stream)
+			return nil, err
+		}
+		refs[event] = ref
+	}
+	return off, nil
+}
+
+// onceStreamEvent calls fn the next time the stream emits event.
+func onceStreamEvent(env Env, stream Value, event string, fn func()) error {
+	listener, err := NewFunction(env, event, func(env Env, args *Args) (interface{}, error) {
+		fn()
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = CallMethod(env, stream, "once", event, listener)
+	return err
+}
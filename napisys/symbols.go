@@ -0,0 +1,44 @@
+package napisys
+
+/*
+#include <node_api.h>
+*/
+import "C"
+import "fmt"
+
+// Symbols
+// Go-backed objects become iterable, disposable or inspectable by defining
+// methods keyed by the well-known symbols of the language, and addons share
+// symbols with JavaScript through the global registry of Symbol.for, which
+// SymbolFor binds. Symbols are used as keys with GetProperty and SetProperty,
+// or with the Symbol field of a Property given to DefineProperties.
+
+// WellKnownSymbol is the name of a well-known symbol, a property of the global
+// Symbol constructor.
+type WellKnownSymbol string
+
+// Well-known symbols.
+const (
+	SymbolIterator      WellKnownSymbol = "iterator"
+	SymbolAsyncIterator WellKnownSymbol = "asyncIterator"
+	SymbolToStringTag   WellKnownSymbol = "toStringTag"
+	SymbolHasInstance   WellKnownSymbol = "hasInstance"
+	SymbolDispose       WellKnownSymbol = "dispose"
+	SymbolAsyncDispose  WellKnownSymbol = "asyncDispose"
+)
+
+// WellKnown function returns a well-known symbol, such as Symbol.iterator.
+// [in] env: The environment that the API is invoked under.
+// [in] symbol: The name of the symbol.
+// Returns an error if the runtime does not define the symbol, as older
+// versions of Node.js do for Symbol.dispose and Symbol.asyncDispose.
+func WellKnown(env Env, symbol WellKnownSymbol) (Value, error) {
+	res, err := globalValue(env, "Symbol", string(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if t, _ := TypeOf(env, res); t != C.napi_symbol {
+		return nil, fmt.Errorf("napisys: Symbol.%s is not supported by this runtime", symbol)
+	}
+	return res, nil
+}
@@ -0,0 +1,151 @@
+package napisys
+
+/*
+#include "gonapi.h"
+*/
+import "C"
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+)
+
+// Type-tagged Go values
+// WrapObject and NewExternal attach a Go value to a JavaScript object or
+// external, and UnwrapObject and UnwrapExternal get it back. Unlike Wrap and
+// GetValueExternal, which hand out whatever pointer the object holds, the
+// object is type-tagged for the Go type of the value, and the tag is checked
+// before the value is returned: a JavaScript caller passing the wrong object
+// gets a TypeError instead of crashing the process. The tag of each Go type is
+// drawn at random the first time the type is used, so it is never shared with
+// another addon. The value is held through a cgo.Handle released when the
+// object is garbage collected.
+
+var typeTags sync.Map // map[reflect.Type]TypeTag
+
+// typeTagOf returns the tag of the Go type T.
+func typeTagOf[T any]() TypeTag {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if tag, ok := typeTags.Load(t); ok {
+		return tag.(TypeTag)
+	}
+	var b [16]byte
+	rand.Read(b[:])
+	tag := NewTypeTag(binary.LittleEndian.Uint64(b[:8]), binary.LittleEndian.Uint64(b[8:]))
+	actual, _ := typeTags.LoadOrStore(t, tag)
+	return actual.(TypeTag)
+}
+
+// WrapObject function attaches v to object and tags object for the type T.
+// An object can only be wrapped and tagged once.
+// [in] env: The environment that the API is invoked under.
+// [in] object: The JavaScript object to attach v to, typically the this of a
+// constructor.
+// [in] v: The Go value to attach.
+func WrapObject[T any](env Env, object Value, v T) error {
+	switch status := TypeTagObject(env, object, typeTagOf[T]()); status {
+	case C.napi_ok:
+	case C.napi_invalid_arg:
+		return &JSError{Name: "TypeError", Message: "The object is already wrapped or type-tagged"}
+	default:
+		return pendingError(env, status)
+	}
+	handle := cgo.NewHandle(v)
+	if status := Status(C.WrapGoValue(env, object, C.uintptr_t(handle))); status != C.napi_ok {
+		handle.Delete()
+		return pendingError(env, status)
+	}
+	return nil
+}
+
+// UnwrapObject function returns the value attached to object by WrapObject.
+// [in] env: The environment that the API is invoked under.
+// [in] object: The JavaScript object to retrieve the value from.
+// Returns a TypeError *JSError if object does not hold a value of type T.
+func UnwrapObject[T any](env Env, object Value) (T, error) {
+	var zero T
+	if err := checkTypeTag[T](env, object); err != nil {
+		return zero, err
+	}
+	raw, status := Unwrap(env, object)
+	if status != C.napi_ok {
+		return zero, pendingError(env, status)
+	}
+	return handleValue[T](raw)
+}
+
+// NewExternal function creates a JavaScript external holding v, tagged for
+// the type T.
+// [in] env: The environment that the API is invoked under.
+// [in] v: The Go value to hold.
+func NewExternal[T any](env Env, v T) (Value, error) {
+	handle := cgo.NewHandle(v)
+	var res C.napi_value
+	if status := Status(C.CreateGoExternal(env, C.uintptr_t(handle), &res)); status != C.napi_ok {
+		handle.Delete()
+		return nil, pendingError(env, status)
+	}
+	// The handle now belongs to the external, whose finalizer deletes it.
+	if status := TypeTagObject(env, Value(res), typeTagOf[T]()); status != C.napi_ok {
+		return nil, pendingError(env, status)
+	}
+	return Value(res), nil
+}
+
+// UnwrapExternal function returns the value held by an external created by
+// NewExternal.
+// [in] env: The environment that the API is invoked under.
+// [in] value: The JavaScript external to retrieve the value from.
+// Returns a TypeError *JSError if value does not hold a value of type T.
+func UnwrapExternal[T any](env Env, value Value) (T, error) {
+	var zero T
+	if t, _ := TypeOf(env, value); t != C.napi_external {
+		return zero, typeTagError[T]()
+	}
+	if err := checkTypeTag[T](env, value); err != nil {
+		return zero, err
+	}
+	raw, status := GetValueExternal(env, value)
+	if status != C.napi_ok {
+		return zero, pendingError(env, status)
+	}
+	return handleValue[T](raw)
+}
+
+// checkTypeTag fails unless value is tagged for the type T.
+func checkTypeTag[T any](env Env, value Value) error {
+	switch t, _ := TypeOf(env, value); t {
+	case C.napi_object, C.napi_function, C.napi_external:
+	default:
+		return typeTagError[T]()
+	}
+	ok, status := CheckObjectTypeTag(env, value, typeTagOf[T]())
+	if status != C.napi_ok {
+		return pendingError(env, status)
+	}
+	if !ok {
+		return typeTagError[T]()
+	}
+	return nil
+}
+
+// handleValue returns the value of the handle stored as a native pointer.
+func handleValue[T any](raw unsafe.Pointer) (T, error) {
+	v, ok := cgo.Handle(uintptr(raw)).Value().(T)
+	if !ok {
+		return v, typeTagError[T]()
+	}
+	return v, nil
+}
+
+func typeTagError[T any]() error {
+	return &JSError{
+		Name:    "TypeError",
+		Code:    "ERR_INVALID_ARG_TYPE",
+		Message: fmt.Sprintf("The object does not hold a Go %s", reflect.TypeOf((*T)(nil)).Elem()),
+	}
+}